@@ -0,0 +1,75 @@
+package main
+
+import (
+	"Lang/lang"
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"time"
+)
+
+//go:embed bench/*.lang
+var bench_programs embed.FS
+
+// how long each way of running a program is timed for, at least
+const bench_time = time.Second
+
+// lang bench times the tree walker against the vm on the built in programs, or the files given,
+// and checks they print the same thing, go test -bench . ./lang times the built in ones with allocations too
+func bench(files []string) {
+	sources := map[string][]byte{}
+	if len(files) == 0 {
+		entries, _ := bench_programs.ReadDir("bench")
+		for _, entry := range entries {
+			files = append(files, path.Join("bench", entry.Name()))
+			sources[files[len(files)-1]], _ = bench_programs.ReadFile(files[len(files)-1])
+		}
+	}
+	fmt.Printf("%-24s %14s %14s %8s\n", "program", "tree walker", "vm", "speedup")
+	failed := false
+	for _, file := range files {
+		src, embedded := sources[file]
+		if !embedded {
+			var err error
+			src, err = os.ReadFile(file)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+		prog, diags := lang.CompileReader(bytes.NewReader(src), lang.CompileOptions{File: file})
+		if prog == nil {
+			report("text", diags)
+			os.Exit(1)
+		}
+		walked, walked_out := time_runs(prog, false)
+		compiled, compiled_out := time_runs(prog, true)
+		fmt.Printf("%-24s %14v %14v %7.2fx\n", file, walked, compiled, float64(walked)/float64(compiled))
+		if walked_out != compiled_out {
+			fmt.Printf("    the vm did something different:\n    tree walker: %.200q\n    vm:          %.200q\n", walked_out, compiled_out)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// how long one run takes on average, and what the first one printed and stopped with
+func time_runs(prog *lang.Program, vm bool) (time.Duration, string) {
+	var out bytes.Buffer
+	_, err := prog.Run(context.Background(), lang.RunOptions{Output: &out, VM: vm})
+	if err != nil {
+		fmt.Fprintf(&out, "stopped with %v", err)
+	}
+	runs := 0
+	start := time.Now()
+	for time.Since(start) < bench_time {
+		prog.Run(context.Background(), lang.RunOptions{VM: vm})
+		runs++
+	}
+	return time.Since(start) / time.Duration(runs), out.String()
+}
@@ -0,0 +1,73 @@
+package lang
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type bench_program struct {
+	name string
+	*Program
+}
+
+// the programs in bench/ at the top of the repo, compiled
+func bench_programs(tb testing.TB) []bench_program {
+	tb.Helper()
+	files, err := filepath.Glob("../bench/*.lang")
+	if err != nil || len(files) == 0 {
+		tb.Fatalf("no bench programs: %v", err)
+	}
+	programs := []bench_program{}
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		prog, diags := CompileReader(bytes.NewReader(src), CompileOptions{File: file})
+		if prog == nil {
+			tb.Fatalf("%s: %v", file, diags)
+		}
+		programs = append(programs, bench_program{name: strings.TrimSuffix(filepath.Base(file), ".lang"), Program: prog})
+	}
+	return programs
+}
+
+// the vm is only worth timing if it does the same thing
+func TestBenchProgramsMatch(t *testing.T) {
+	for _, prog := range bench_programs(t) {
+		var walked, compiled bytes.Buffer
+		_, walked_err := prog.Run(context.Background(), RunOptions{Output: &walked})
+		_, compiled_err := prog.Run(context.Background(), RunOptions{Output: &compiled, VM: true})
+		if walked_err != nil || compiled_err != nil {
+			t.Errorf("%s: tree walker stopped with %v, vm with %v", prog.name, walked_err, compiled_err)
+		}
+		if walked.String() != compiled.String() {
+			t.Errorf("%s: the vm printed\n%.200q\nthe tree walker printed\n%.200q", prog.name, compiled.String(), walked.String())
+		}
+	}
+}
+
+// go test -bench . -run ^$ ./lang
+func BenchmarkPrograms(b *testing.B) {
+	for _, prog := range bench_programs(b) {
+		prog := prog
+		for _, engine := range []struct {
+			name string
+			vm   bool
+		}{{"tree_walker", false}, {"vm", true}} {
+			vm := engine.vm
+			b.Run(prog.name+"/"+engine.name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := prog.Run(context.Background(), RunOptions{VM: vm}); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
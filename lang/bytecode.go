@@ -0,0 +1,267 @@
+package lang
+
+// the tree compiled to bytecode for the stack machine in vm.go
+// nodes the compiler doesn't know are kept as they are and walked like the tree walker would,
+// that is everything to do with solving, so the solver works the same either way
+
+type opcode byte
+
+const (
+	op_const          opcode = iota //push constants[arg]
+	op_get                          //push the variable the GetNode nodes[arg] reads
+	op_set                          //pop into the variable names[arg]
+	op_declare                      //the variable names[arg] exists but has no value
+	op_get_local                    //op_get for gets[arg], a variable of the function being run
+	op_set_local                    //op_set for the variable in slot arg
+	op_declare_local                //op_declare for the variable in slot arg
+	op_declare_range                //pop high and low, the range of the DeclareNode nodes[arg]
+	op_binary                       //pop right and left, push the BinaryOpNode nodes[arg] applied to them
+	op_negate                       //pop a number, push it negated for the NegateNode nodes[arg]
+	op_check_index                  //the top has to be an int to index the IndexNode nodes[arg] with
+	op_index                        //pop target and index, push the element of the IndexNode nodes[arg]
+	op_property                     //pop target, push the PropertyNode nodes[arg] of it
+	op_tuple                        //pop as many values as the TupleLiteral nodes[arg] has, push them as a tuple
+	op_print                        //pop and print for the PrintStatement nodes[arg]
+	op_call                         //pop the arguments of calls[arg] and run the function, push what it returns
+	op_call_host                    //the same for a go function
+	op_return                       //pop what the function returns and leave it
+	op_return_nothing               //leave the function
+	op_pop                          //throw away the top, what a call on its own line returned
+	op_step                         //count a step for the statement nodes[arg]
+	op_for                          //pop the solution set the ForInNode nodes[arg] loops over and make the scope of its body
+	op_next                         //the next solution in the loop's scope, leave it and jump to arg when there are no more
+	op_end_loop                     //jump back to arg
+	op_walk                         //run nodes[arg] with the tree walker
+	op_walk_value                   //the same, and push what it evaluated to
+)
+
+type instruction struct {
+	op  opcode
+	arg int
+}
+
+// a top level statement or function body
+type chunk struct {
+	code      []instruction
+	constants []Value
+	names     []string
+	nodes     []ASTNode
+	calls     []call_site
+	locals    []string //the names of the slots a function keeps its variables in
+	gets      []local_get
+}
+
+type local_get struct {
+	slot int
+	node *GetNode
+}
+
+// a call of a function known when compiling, host is nil if it is one of the program's own
+type call_site struct {
+	node     *CallNode
+	function *compiled_function
+	host     *HostFunction
+}
+
+type compiled_function struct {
+	definition *FunctionDefinition
+	body       *chunk
+	params     []int //the slot of each parameter
+}
+
+// a whole program, one chunk for each top level statement so it can be run with the same steps the tree walker takes
+type bytecode struct {
+	lines     []*chunk
+	functions map[string]*compiled_function
+}
+
+type compiler struct {
+	program *bytecode
+	host    *Host
+	current *chunk
+	slots   map[string]int //slots of the function being compiled, nil at the top level
+}
+
+func compile_program(ast []ASTNode, host *Host) *bytecode {
+	program := &bytecode{lines: make([]*chunk, len(ast)), functions: map[string]*compiled_function{}}
+	cp := &compiler{program: program, host: host}
+	//every function exists before any body is compiled so they can call each other
+	for _, line := range ast {
+		if fd, is_function := line.(*FunctionDefinition); is_function {
+			program.functions[fd.name] = &compiled_function{definition: fd, body: &chunk{}}
+		}
+	}
+	for _, fn := range program.functions {
+		cp.current = fn.body
+		cp.slots = map[string]int{}
+		for _, name := range fn.definition.parameterNames {
+			fn.params = append(fn.params, cp.slot(name))
+		}
+		cp.block(fn.definition.lines)
+	}
+	cp.slots = nil
+	for i, line := range ast {
+		program.lines[i] = &chunk{}
+		cp.current = program.lines[i]
+		cp.statement(line)
+	}
+	return program
+}
+
+func (cp *compiler) emit(op opcode, arg int) int {
+	cp.current.code = append(cp.current.code, instruction{op: op, arg: arg})
+	return len(cp.current.code) - 1
+}
+
+func (cp *compiler) node(n ASTNode) int {
+	cp.current.nodes = append(cp.current.nodes, n)
+	return len(cp.current.nodes) - 1
+}
+
+func (cp *compiler) name(name string) int {
+	cp.current.names = append(cp.current.names, name)
+	return len(cp.current.names) - 1
+}
+
+// the slot for a variable of the function being compiled, everything it declares or sets gets one
+func (cp *compiler) slot(name string) int {
+	if slot, exists := cp.slots[name]; exists {
+		return slot
+	}
+	cp.slots[name] = len(cp.current.locals)
+	cp.current.locals = append(cp.current.locals, name)
+	return cp.slots[name]
+}
+
+func (cp *compiler) constant(v Value) int {
+	cp.current.constants = append(cp.current.constants, v)
+	return len(cp.current.constants) - 1
+}
+
+// lines with a step before each, like BlockNode
+func (cp *compiler) block(lines []ASTNode) {
+	for _, line := range lines {
+		cp.emit(op_step, cp.node(line))
+		cp.statement(line)
+	}
+}
+
+func (cp *compiler) statement(n ASTNode) {
+	switch node := n.(type) {
+	case *DeclareNode:
+		if cp.slots != nil {
+			cp.emit(op_declare_local, cp.slot(node.name))
+		} else {
+			cp.emit(op_declare, cp.name(node.name))
+		}
+		if node.low != nil {
+			cp.expression(node.low)
+			cp.expression(node.high)
+			cp.emit(op_declare_range, cp.node(node))
+		}
+	case *SetNode:
+		cp.expression(node.from)
+		if cp.slots != nil {
+			cp.emit(op_set_local, cp.slot(node.to))
+		} else {
+			cp.emit(op_set, cp.name(node.to))
+		}
+	case *PrintStatement:
+		cp.expression(node.argument)
+		cp.emit(op_print, cp.node(node))
+	case *FunctionDefinition:
+		//compiled on its own, nothing happens when it is reached
+	case *ReturnNode:
+		if node.value == nil {
+			cp.emit(op_return_nothing, 0)
+			return
+		}
+		cp.expression(node.value)
+		cp.emit(op_return, 0)
+	case *ForInNode:
+		cp.expression(node.set)
+		cp.emit(op_for, cp.node(node))
+		next := cp.emit(op_next, 0)
+		cp.block(node.body.lines)
+		cp.emit(op_end_loop, next)
+		cp.current.code[next].arg = len(cp.current.code)
+	case *CallNode:
+		cp.expression(node)
+		cp.emit(op_pop, 0)
+	default:
+		cp.emit(op_walk, cp.node(n))
+	}
+}
+
+func (cp *compiler) expression(n ASTNode) {
+	switch node := n.(type) {
+	case *IntLiteral:
+		cp.emit(op_const, cp.constant(&IntType{value: node.value}))
+	case *FloatLiteral:
+		cp.emit(op_const, cp.constant(&FloatType{value: node.value}))
+	case *BoolLiteral:
+		cp.emit(op_const, cp.constant(&BoolType{value: node.value}))
+	case *StringLiteral:
+		cp.emit(op_const, cp.constant(&StringType{value: node.value}))
+	case *GetNode:
+		if slot, is_local := cp.slots[node.name]; is_local {
+			cp.current.gets = append(cp.current.gets, local_get{slot: slot, node: node})
+			cp.emit(op_get_local, len(cp.current.gets)-1)
+			return
+		}
+		cp.emit(op_get, cp.node(node))
+	case *BinaryOpNode:
+		cp.expression(node.left)
+		cp.expression(node.right)
+		cp.emit(op_binary, cp.node(node))
+	case *NegateNode:
+		cp.expression(node.operand)
+		cp.emit(op_negate, cp.node(node))
+	case *IndexNode:
+		cp.expression(node.index)
+		at := cp.node(node)
+		cp.emit(op_check_index, at)
+		cp.expression(node.target)
+		cp.emit(op_index, at)
+	case *PropertyNode:
+		cp.expression(node.target)
+		cp.emit(op_property, cp.node(node))
+	case *TupleLiteral:
+		for _, value := range node.values {
+			cp.expression(value)
+		}
+		cp.emit(op_tuple, cp.node(node))
+	case *CallNode:
+		cp.call(node)
+	default:
+		cp.emit(op_walk_value, cp.node(n))
+	}
+}
+
+// calls are resolved here, anything that would fail before the arguments are evaluated is left to the tree walker
+func (cp *compiler) call(node *CallNode) {
+	site := call_site{node: node}
+	op := op_call
+	if fn, exists := cp.program.functions[node.name]; exists {
+		if len(node.args) != len(fn.definition.parameterNames) {
+			cp.emit(op_walk_value, cp.node(node))
+			return
+		}
+		site.function = fn
+	} else if hf := cp.host.lookup(node.name); hf != nil {
+		if hf.params != nil && len(node.args) != len(hf.params) {
+			cp.emit(op_walk_value, cp.node(node))
+			return
+		}
+		site.host = hf
+		op = op_call_host
+	} else {
+		cp.emit(op_walk_value, cp.node(node))
+		return
+	}
+	for _, arg := range node.args {
+		cp.expression(arg)
+	}
+	cp.current.calls = append(cp.current.calls, site)
+	cp.emit(op, len(cp.current.calls)-1)
+}
@@ -0,0 +1,86 @@
+package lang
+
+import (
+	_ "embed"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// an error that has no place in the source, like too many errors or an unreadable file, but still has a code
+type CodedError struct {
+	code string
+	err  error
+}
+
+func (ce CodedError) Error() string {
+	return fmt.Sprintf("error[%s]: %v", ce.code, ce.err)
+}
+func (ce CodedError) Unwrap() error {
+	return ce.err
+}
+
+// every error code with a longer description and examples of it going wrong and being fixed
+// a section starts with a line like "## E0201: undefined variable" and goes until the next one
+//
+//go:embed explanations.md
+var explanations_src string
+
+type Explanation struct {
+	code  string
+	title string
+	body  string
+}
+
+func (ex Explanation) String() string {
+	return fmt.Sprintf("%s: %s\n\n%s", ex.code, ex.title, ex.body)
+}
+
+var explanations = parse_explanations(explanations_src)
+
+func parse_explanations(src string) map[string]Explanation {
+	found := map[string]Explanation{}
+	var current *Explanation
+	finish := func() {
+		if current != nil {
+			current.body = strings.TrimSpace(current.body) + "\n"
+			found[current.code] = *current
+		}
+	}
+	for _, line := range strings.Split(src, "\n") {
+		if strings.HasPrefix(line, "## ") {
+			finish()
+			code, title, _ := strings.Cut(strings.TrimPrefix(line, "## "), ":")
+			current = &Explanation{code: strings.TrimSpace(code), title: strings.TrimSpace(title)}
+			continue
+		}
+		if current != nil {
+			current.body += line + "\n"
+		}
+	}
+	finish()
+	return found
+}
+
+// the explanation of code, or an error saying which code was probably meant
+func Explain(code string) (Explanation, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if ex, found := explanations[code]; found {
+		return ex, nil
+	}
+	if closest, found := closest_name(code, names_of(explanations)); found {
+		return Explanation{}, fmt.Errorf("no error code %s, did you mean %s?", code, closest)
+	}
+	return Explanation{}, fmt.Errorf("no error code %s, `lang explain` lists them all", code)
+}
+
+// one line per code, in order
+func ExplanationIndex() []string {
+	codes := names_of(explanations)
+	sort.Strings(codes)
+	index := []string{}
+	for _, code := range codes {
+		index = append(index, fmt.Sprintf("%s  %s", code, explanations[code].title))
+	}
+	return index
+}
@@ -0,0 +1,124 @@
+package lang
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// every code the source can report has to be in explanations.md
+func TestEveryCodeExplained(t *testing.T) {
+	files, err := os.ReadDir(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code_pattern := regexp.MustCompile(`"([EWI]\d{4})"`)
+	codes := map[string]bool{}
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".go") || strings.HasSuffix(f.Name(), "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range code_pattern.FindAllStringSubmatch(string(src), -1) {
+			codes[m[1]] = true
+		}
+	}
+	if len(codes) < 40 {
+		t.Fatalf("only found %d codes, the pattern is probably wrong", len(codes))
+	}
+	for code := range codes {
+		if _, err := Explain(code); err != nil {
+			t.Errorf("%s: %v", code, err)
+		}
+	}
+	if len(ExplanationIndex()) != len(explanations) {
+		t.Errorf("the index has %d codes, there are %d", len(ExplanationIndex()), len(explanations))
+	}
+}
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		code string
+		want string //what the title or the error has in it
+	}{
+		{"E0201", "undefined variable"},
+		{"e0201", "undefined variable"},
+		{" E0201\n", "undefined variable"},
+		{"W0103", "shadow"},
+		{"E0210", "did you mean"},
+		{"nonsense", "`lang explain` lists them all"},
+	}
+	for _, test := range tests {
+		ex, err := Explain(test.code)
+		got := ex.title
+		if err != nil {
+			got = err.Error()
+		}
+		if !strings.Contains(got, test.want) {
+			t.Errorf("%q: got %q, want it to have %q", test.code, got, test.want)
+		}
+	}
+}
+
+// the ```go examples have to do what they say, "fails" and "warns" ones report their code and "fixed" ones don't report anything that stops them
+func TestExplanationExamples(t *testing.T) {
+	block := regexp.MustCompile("(?s)```go\n// (\\w+)([^\n]*)\n(.*?)```")
+	limit := regexp.MustCompile(`-max-(steps|depth|length) (\d+)`)
+	checked := 0
+	for code, ex := range explanations {
+		for _, m := range block.FindAllStringSubmatch(ex.body, -1) {
+			kind, src := m[1], m[3]
+			var limits Limits
+			if l := limit.FindStringSubmatch(m[2]); l != nil {
+				n, _ := strconv.Atoi(l[2])
+				switch l[1] {
+				case "steps":
+					limits.Steps = n
+				case "depth":
+					limits.CallDepth = n
+				case "length":
+					limits.MaxLength = n
+				}
+			}
+			for _, vm := range []bool{false, true} {
+				reported := example_codes(src, limits, vm)
+				switch kind {
+				case "fails", "warns":
+					if !strings.Contains(reported, code) {
+						t.Errorf("%s %s, vm %v: reported %q", code, kind, vm, reported)
+					}
+				case "fixed":
+					if strings.Contains(reported, code) || strings.Contains(reported, "error") {
+						t.Errorf("%s fixed, vm %v: still reported %q", code, vm, reported)
+					}
+				default:
+					t.Errorf("%s: an example has to say if it fails, warns or is fixed, not %q", code, kind)
+				}
+			}
+			checked++
+		}
+	}
+	if checked < 60 {
+		t.Errorf("only %d examples checked", checked)
+	}
+}
+
+// the severity and code of everything compiling and running src reported, as one string
+func example_codes(src string, limits Limits, vm bool) string {
+	prog, diags := CompileReader(strings.NewReader(src), CompileOptions{})
+	if prog != nil {
+		res, _ := prog.Run(context.Background(), RunOptions{Limits: limits, VM: vm})
+		diags = append(diags, res.Diagnostics...)
+	}
+	reported := []string{}
+	for _, d := range diags {
+		reported = append(reported, d.Severity+" "+d.Code)
+	}
+	return strings.Join(reported, ", ")
+}
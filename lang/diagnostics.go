@@ -0,0 +1,212 @@
+package lang
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"unicode/utf8"
+)
+
+// an error or warning in a form tools can read, lines and columns count from 1 and columns are in characters
+type Diagnostic struct {
+	Severity   string   `json:"severity"`
+	Code       string   `json:"code,omitempty"`
+	File       string   `json:"file,omitempty"`
+	Line       int      `json:"line,omitempty"`
+	Column     int      `json:"column,omitempty"`
+	EndColumn  int      `json:"end_column,omitempty"`
+	Message    string   `json:"message"`
+	Suggestion string   `json:"suggestion,omitempty"`
+	Fix        *Fix     `json:"fix,omitempty"`
+	Notes      []string `json:"notes,omitempty"` //extra context, like the call stack of a runtime error
+
+	text string //how the cli shows it, with the source line and a caret
+}
+
+func (d Diagnostic) String() string {
+	if d.text != "" {
+		return d.text
+	}
+	return fmt.Sprintf("%s:%d:%d: %s[%s]: %s", d.File, d.Line, d.Column, d.Severity, d.Code, d.Message)
+}
+
+// true if any of diags is worse than a warning
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == ErrorSeverity.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// a FixIt as tools see it, same columns as Diagnostic
+type Fix struct {
+	Line        int    `json:"line"`
+	Column      int    `json:"column"`
+	EndColumn   int    `json:"end_column"`
+	Replacement string `json:"replacement"`
+}
+
+// everything collected so far as Diagnostics, lines is the source for turning byte indexes into columns
+func (ec *ErrorCollector) Diagnostics(lines []string) []Diagnostic {
+	diags := []Diagnostic{}
+	for _, err := range ec.errs {
+		diags = append(diags, diagnostic_of(err, lines))
+	}
+	return diags
+}
+
+// orders diags by where they are in the file, the lexer, parser and lint each find theirs separately
+// ones with no place in the file, like too many errors, stay at the end in the order they came
+func sort_diagnostics(diags []Diagnostic) {
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i], diags[j]
+		if (a.Line == 0) != (b.Line == 0) {
+			return b.Line == 0
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
+
+func diagnostic_of(err error, lines []string) Diagnostic {
+	d := diagnostic_fields(err, lines)
+	d.text = with_source(err, lines).Error()
+	return d
+}
+
+func diagnostic_fields(err error, lines []string) Diagnostic {
+	var re RuntimeError
+	if errors.As(err, &re) {
+		d := located_diagnostic(re.LocatedError, lines)
+		d.Notes = append(d.Notes, re.calls()...)
+		return d
+	}
+	var le LocatedError
+	if errors.As(err, &le) {
+		return located_diagnostic(le, lines)
+	}
+	var ce CodedError
+	if errors.As(err, &ce) {
+		return Diagnostic{Severity: "error", Code: ce.code, Message: ce.err.Error()}
+	}
+	return Diagnostic{Severity: "error", Message: err.Error()}
+}
+
+func located_diagnostic(le LocatedError, lines []string) Diagnostic {
+	line_src := source_line(lines, le.line)
+	d := Diagnostic{
+		Severity:   le.severity.String(),
+		Code:       le.code,
+		File:       le.file,
+		Line:       le.line,
+		Column:     column_of(line_src, le.index),
+		Message:    le.msg,
+		Suggestion: le.suggestion,
+	}
+	d.EndColumn = d.Column + 1
+	if le.end > le.index {
+		d.EndColumn = column_of(line_src, le.end)
+	}
+	if le.fix != nil {
+		fix_src := source_line(lines, le.fix.line)
+		d.Fix = &Fix{
+			Line:        le.fix.line,
+			Column:      column_of(fix_src, le.fix.start),
+			EndColumn:   column_of(fix_src, le.fix.end),
+			Replacement: le.fix.replacement,
+		}
+	}
+	return d
+}
+
+// the 1 based character column of byte index on line_src
+func column_of(line_src string, index int) int {
+	if index > len(line_src) {
+		return index + 1
+	}
+	return utf8.RuneCountInString(line_src[:index]) + 1
+}
+
+func WriteJSONDiagnostics(w io.Writer, diags []Diagnostic) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(diags)
+}
+
+// SARIF 2.1.0, what CI code scanning understands
+func WriteSARIFDiagnostics(w io.Writer, diags []Diagnostic) error {
+	type object = map[string]any
+	results := []object{}
+	rules := []object{}
+	seen_rules := map[string]bool{}
+	for _, d := range diags {
+		level := d.Severity
+		if level == "info" {
+			level = "note"
+		}
+		result := object{
+			"level":   level,
+			"message": object{"text": sarif_message(d)},
+		}
+		if d.Code != "" {
+			result["ruleId"] = d.Code
+			if !seen_rules[d.Code] {
+				seen_rules[d.Code] = true
+				rule := object{"id": d.Code}
+				if ex, found := explanations[d.Code]; found {
+					rule["shortDescription"] = object{"text": ex.title}
+				}
+				rules = append(rules, rule)
+			}
+		}
+		if d.Line > 0 {
+			result["locations"] = []object{{
+				"physicalLocation": object{
+					"artifactLocation": object{"uri": d.File},
+					"region":           object{"startLine": d.Line, "startColumn": d.Column, "endColumn": d.EndColumn},
+				},
+			}}
+		}
+		if d.Fix != nil {
+			result["fixes"] = []object{{
+				"description": object{"text": fmt.Sprintf("replace with `%s`", d.Fix.Replacement)},
+				"artifactChanges": []object{{
+					"artifactLocation": object{"uri": d.File},
+					"replacements": []object{{
+						"deletedRegion":   object{"startLine": d.Fix.Line, "startColumn": d.Fix.Column, "endColumn": d.Fix.EndColumn},
+						"insertedContent": object{"text": d.Fix.Replacement},
+					}},
+				}},
+			}}
+		}
+		results = append(results, result)
+	}
+	log := object{
+		"version": "2.1.0",
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"runs": []object{{
+			"tool":    object{"driver": object{"name": "lang", "rules": rules}},
+			"results": results,
+		}},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+func sarif_message(d Diagnostic) string {
+	msg := d.Message
+	if d.Suggestion != "" {
+		msg += fmt.Sprintf(", did you mean `%s`?", d.Suggestion)
+	}
+	for _, note := range d.Notes {
+		msg += "\n" + note
+	}
+	return msg
+}
@@ -0,0 +1,198 @@
+package lang
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// undefined functions are only known at the end and lint runs after parsing, the output is still in file order
+func TestDiagnosticsInOrder(t *testing.T) {
+	src := "print f()\nvar 5 int\nvar a int = 1\nvar b int = 2 | 3\nprint g()"
+	_, diags := CompileReader(strings.NewReader(src), CompileOptions{})
+	var out bytes.Buffer
+	if err := WriteJSONDiagnostics(&out, diags); err != nil {
+		t.Fatal(err)
+	}
+	var written []Diagnostic
+	if err := json.Unmarshal(out.Bytes(), &written); err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, d := range written {
+		got = append(got, d.Code)
+	}
+	want := []string{"E0202", "E0105", "E0001", "E0202"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := 1; i < len(written); i++ {
+		a, b := written[i-1], written[i]
+		if a.Line > b.Line || (a.Line == b.Line && a.Column > b.Column) {
+			t.Errorf("%s at %d:%d comes before %s at %d:%d", a.Code, a.Line, a.Column, b.Code, b.Line, b.Column)
+		}
+	}
+}
+
+func TestUnlocatedDiagnosticsLast(t *testing.T) {
+	diags := []Diagnostic{{Code: "E0901"}, {Code: "E0201", Line: 3, Column: 2}, {Code: "E0101", Line: 1, Column: 5}, {Code: "E0102", Line: 1, Column: 1}}
+	sort_diagnostics(diags)
+	got := []string{}
+	for _, d := range diags {
+		got = append(got, d.Code)
+	}
+	if strings.Join(got, " ") != "E0102 E0101 E0201 E0901" {
+		t.Errorf("got %v", got)
+	}
+}
+
+// a bad statement is skipped and parsing carries on, so one compile reports every mistake up to the cap
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"print x\nprint y\nprint z", []string{"E0201", "E0201", "E0201"}},
+		{"var a int = \nvar b int = 1 +\nprint b", []string{"E0102", "E0102"}},
+		{"var a int = (1\nprint q", []string{"E0102", "E0201"}},
+		{"func f() {\n\tprint x\n\tprint y\n}", []string{"E0201", "E0201"}},
+		//the block of a statement that didn't parse is skipped, not taken as statements
+		{"if {\n\tprint x\n}\nprint y", []string{"E0101", "E0201"}},
+		{"}\nprint y", []string{"E0104", "E0201"}},
+		{"print x\nfunc f() {\n\tprint 1\n", []string{"E0201", "E0103"}},
+	}
+	for _, test := range tests {
+		if got := error_codes(test.src, nil); strings.Join(got, " ") != strings.Join(test.want, " ") {
+			t.Errorf("%q: got %v, want %v", test.src, got, test.want)
+		}
+	}
+}
+
+func TestErrorCap(t *testing.T) {
+	src := strings.Repeat("print nope\n", max_errors+10)
+	_, diags := Compile(src)
+	if len(diags) != max_errors+1 {
+		t.Fatalf("got %d diagnostics, want %d errors and the one saying it stopped", len(diags), max_errors)
+	}
+	if last := diags[len(diags)-1]; last.Code != "E0901" || last.Line != 0 {
+		t.Errorf("the last one is %+v", last)
+	}
+	//warnings don't count towards it
+	src = ""
+	for i := 0; i < max_errors+10; i++ {
+		src += fmt.Sprintf("var unused%d int = 1\n", i)
+	}
+	prog, diags := Compile(src)
+	if prog == nil || len(diags) != max_errors+10 || diags[len(diags)-1].Code != "W0101" {
+		t.Errorf("got %d diagnostics, the last %v", len(diags), diags[len(diags)-1])
+	}
+}
+
+func TestJSONDiagnostics(t *testing.T) {
+	src := "var first int = 1\nprint \"é\" | 2\nprint frist"
+	_, diags := CompileReader(strings.NewReader(src), CompileOptions{File: "t.lang"})
+	var out bytes.Buffer
+	if err := WriteJSONDiagnostics(&out, diags); err != nil {
+		t.Fatal(err)
+	}
+	var written []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &written); err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 2 {
+		t.Fatalf("got %s", out.String())
+	}
+	//columns count characters, é is two bytes
+	bar := written[0]
+	want := map[string]any{"severity": "error", "code": "E0001", "file": "t.lang", "line": 2.0, "column": 11.0, "end_column": 12.0}
+	for key, value := range want {
+		if bar[key] != value {
+			t.Errorf("%s is %v, want %v", key, bar[key], value)
+		}
+	}
+	fix, _ := bar["fix"].(map[string]any)
+	if fix["replacement"] != "||" || fix["line"] != 2.0 || fix["column"] != 11.0 || fix["end_column"] != 12.0 {
+		t.Errorf("fix is %v", bar["fix"])
+	}
+	if undefined := written[1]; undefined["code"] != "E0201" || undefined["suggestion"] != "first" {
+		t.Errorf("got %v", undefined)
+	}
+	//an empty list is still a list, so tools don't have to check for null
+	out.Reset()
+	WriteJSONDiagnostics(&out, []Diagnostic{})
+	if strings.TrimSpace(out.String()) != "[]" {
+		t.Errorf("no diagnostics wrote %q", out.String())
+	}
+}
+
+func TestSARIFDiagnostics(t *testing.T) {
+	diags := []Diagnostic{
+		{Severity: "error", Code: "E0001", File: "t.lang", Line: 2, Column: 9, EndColumn: 10, Message: "no such operator `|`", Fix: &Fix{Line: 2, Column: 9, EndColumn: 10, Replacement: "||"}},
+		{Severity: "error", Code: "E0201", File: "t.lang", Line: 3, Column: 7, EndColumn: 12, Message: "frist is undefined", Suggestion: "first"},
+		{Severity: "info", Code: "E0201", File: "t.lang", Line: 4, Column: 1, EndColumn: 2, Message: "again", Notes: []string{"in f called from line 5:1"}},
+		{Severity: "error", Code: "E0901", Message: "too many errors, stopping"},
+	}
+	var out bytes.Buffer
+	if err := WriteSARIFDiagnostics(&out, diags); err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID               string `json:"id"`
+						ShortDescription struct {
+							Text string `json:"text"`
+						} `json:"shortDescription"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID  string `json:"ruleId"`
+				Level   string `json:"level"`
+				Message struct {
+					Text string `json:"text"`
+				} `json:"message"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region map[string]int `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+				Fixes []any `json:"fixes"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Results) != 4 {
+		t.Fatalf("got %s", out.String())
+	}
+	rules := log.Runs[0].Tool.Driver.Rules
+	if len(rules) != 3 || rules[1].ID != "E0201" || rules[1].ShortDescription.Text != explanations["E0201"].title {
+		t.Errorf("each code is one rule: %+v", rules)
+	}
+	results := log.Runs[0].Results
+	if len(results[0].Fixes) != 1 || len(results[1].Fixes) != 0 {
+		t.Errorf("only the first has a fix: %s", out.String())
+	}
+	if region := results[1].Locations[0].PhysicalLocation.Region; region["startLine"] != 3 || region["startColumn"] != 7 || region["endColumn"] != 12 {
+		t.Errorf("region %v", region)
+	}
+	if results[1].Message.Text != "frist is undefined, did you mean `first`?" {
+		t.Errorf("message %q", results[1].Message.Text)
+	}
+	if results[2].Level != "note" || !strings.HasSuffix(results[2].Message.Text, "\nin f called from line 5:1") {
+		t.Errorf("got %+v", results[2])
+	}
+	if len(results[3].Locations) != 0 {
+		t.Errorf("an unlocated error has no location: %+v", results[3])
+	}
+}
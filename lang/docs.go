@@ -0,0 +1,35 @@
+package lang
+
+import "fmt"
+
+// a declaration that had a /// comment above it
+type DocEntry struct {
+	kind string //func or var
+	name string
+	doc  string
+	span Span
+}
+
+func (de DocEntry) String() string {
+	return fmt.Sprintf("%s %s (%s)\n%s", de.kind, de.name, de.span, de.doc)
+}
+
+// every documented declaration at the top level of a program and inside its functions, in the order they are in the source
+// this is what editors and doc generators should use instead of digging through the tree themselves
+func Docs(program []ASTNode) []DocEntry {
+	entries := []DocEntry{}
+	for _, node := range program {
+		switch n := node.(type) {
+		case *FunctionDefinition:
+			if n.doc != "" {
+				entries = append(entries, DocEntry{kind: "func", name: n.name, doc: n.doc, span: n.span})
+			}
+			entries = append(entries, Docs(n.lines)...)
+		case *DeclareNode:
+			if n.doc != "" {
+				entries = append(entries, DocEntry{kind: "var", name: n.name, doc: n.doc, span: n.span})
+			}
+		}
+	}
+	return entries
+}
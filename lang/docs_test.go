@@ -0,0 +1,43 @@
+package lang
+
+import "testing"
+
+func TestDocs(t *testing.T) {
+	src := `/// the answer
+/// to everything
+var answer int = 42
+
+/// not for anything, there is a blank line after it
+
+var plain int = 1
+// an ordinary comment isn't documentation
+var commented int = 2
+/// adds one
+func inc(n int) int {
+	/// inside a function still counts
+	var step int = 1
+	return n + step
+}
+print inc(answer + plain + commented)`
+	prog, diags := Compile(src)
+	if prog == nil {
+		t.Fatal(diags)
+	}
+	want := []DocEntry{
+		{kind: "var", name: "answer", doc: "the answer\nto everything"},
+		{kind: "func", name: "inc", doc: "adds one"},
+		{kind: "var", name: "step", doc: "inside a function still counts"},
+	}
+	got := prog.Docs()
+	if len(got) != len(want) {
+		t.Fatalf("got %v", got)
+	}
+	for i := range want {
+		if got[i].kind != want[i].kind || got[i].name != want[i].name || got[i].doc != want[i].doc {
+			t.Errorf("got %v, want %v", got[i], want[i])
+		}
+	}
+	if got[0].span.line != 3 || got[1].span.line != 11 || got[2].span.line != 13 {
+		t.Errorf("documented on lines %d, %d and %d", got[0].span.line, got[1].span.line, got[2].span.line)
+	}
+}
@@ -0,0 +1,233 @@
+package lang
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Go functions scripts can call and global variables they start with,
+// give it to CompileOptions so the parser knows what they take and return
+type Host struct {
+	functions map[string]*HostFunction
+	globals   map[string]host_global
+}
+
+func NewHost() *Host {
+	return &Host{functions: map[string]*HostFunction{}, globals: map[string]host_global{}}
+}
+
+type host_global struct {
+	value      any //turned into a Value at the start of every run, so runs don't share anything
+	value_type ValueType
+}
+
+// a Go function bound to a name scripts can call
+type HostFunction struct {
+	name       string
+	params     []ValueType //nil if it takes any number of anything
+	returnType ValueType
+	call       func(args []Value) (Value, error)
+}
+
+func (hf *HostFunction) String() string {
+	if hf.params == nil {
+		return fmt.Sprintf("%s(...) %v", hf.name, hf.returnType)
+	}
+	return fmt.Sprintf("%s%v %v", hf.name, hf.params, hf.returnType)
+}
+
+// hf.call, a panic in the go function stops the script with an error instead of taking the host down with it
+func (hf *HostFunction) call_recovering(args []Value) (result Value, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			result, err = nil, fmt.Errorf("panicked: %v", p)
+		}
+	}()
+	return hf.call(args)
+}
+
+var error_interface = reflect.TypeOf((*error)(nil)).Elem()
+
+// binds fn to name, fn is either
+//
+//	func(args []Value) (Value, error)
+//
+// which gets the arguments as they are, however many there are, or any other func whose parameters and result
+// are bools, ints, floats, strings, slices of those or Values, with an optional error as the last result
+// the arguments are checked and converted before fn is called, a non nil error or a panic stops the script
+func (h *Host) Register(name string, fn any) error {
+	if !is_name(name) {
+		return fmt.Errorf("%q can't be the name of a function", name)
+	}
+	if _, exists := h.functions[name]; exists {
+		return fmt.Errorf("function %s is already registered", name)
+	}
+	fv := reflect.ValueOf(fn)
+	if fv.Kind() != reflect.Func || fv.IsNil() {
+		return fmt.Errorf("%s: expected a func, not %T", name, fn)
+	}
+	if generic, is_generic := fn.(func([]Value) (Value, error)); is_generic {
+		h.functions[name] = &HostFunction{name: name, returnType: NoType, call: generic}
+		return nil
+	}
+	hf, err := reflect_host_function(name, fv)
+	if err != nil {
+		return err
+	}
+	h.functions[name] = hf
+	return nil
+}
+
+func reflect_host_function(name string, fv reflect.Value) (*HostFunction, error) {
+	ft := fv.Type()
+	if ft.IsVariadic() {
+		return nil, fmt.Errorf("%s: variadic functions aren't supported, take a slice instead", name)
+	}
+	hf := &HostFunction{name: name, params: []ValueType{}, returnType: NoType}
+	for i := 0; i < ft.NumIn(); i++ {
+		param_type, ok := value_type_of(ft.In(i))
+		if !ok {
+			return nil, fmt.Errorf("%s: scripts can't pass a %v", name, ft.In(i))
+		}
+		hf.params = append(hf.params, param_type)
+	}
+	results := ft.NumOut()
+	returns_error := results > 0 && ft.Out(results-1) == error_interface
+	if returns_error {
+		results--
+	}
+	if results > 1 {
+		return nil, fmt.Errorf("%s: can return one value and an error at most", name)
+	}
+	if results == 1 {
+		return_type, ok := value_type_of(ft.Out(0))
+		if !ok {
+			return nil, fmt.Errorf("%s: scripts can't be given a %v", name, ft.Out(0))
+		}
+		hf.returnType = return_type
+	}
+	hf.call = func(args []Value) (Value, error) {
+		in := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			converted, err := from_value(arg, ft.In(i))
+			if err != nil {
+				return nil, argument_error{index: i, err: err}
+			}
+			in[i] = converted
+		}
+		out := fv.Call(in)
+		if returns_error && !out[len(out)-1].IsNil() {
+			return nil, out[len(out)-1].Interface().(error)
+		}
+		if results == 0 {
+			return nil, nil
+		}
+		return to_value(out[0])
+	}
+	return hf, nil
+}
+
+// an argument that could not be turned into what the Go function takes, it is a mistake in the script
+// rather than the function failing
+type argument_error struct {
+	index int
+	err   error
+}
+
+func (ae argument_error) Error() string {
+	return fmt.Sprintf("argument %d: %v", ae.index+1, ae.err)
+}
+
+// declares a global variable every run of the program starts with, value can be anything ToValue takes
+// its type is fixed from here on, RunOptions.Globals can give it a different value of the same type
+func (h *Host) SetGlobal(name string, value any) error {
+	if !is_name(name) {
+		return fmt.Errorf("%q can't be the name of a variable", name)
+	}
+	v, err := ToValue(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if v == nil {
+		return fmt.Errorf("%s: a global needs a value to have a type", name)
+	}
+	h.globals[name] = host_global{value: value, value_type: v.Type()}
+	return nil
+}
+
+// the types of the globals, a new map every time since the parser adds to it
+func (h *Host) global_types() map[string]ValueType {
+	types := map[string]ValueType{}
+	if h == nil {
+		return types
+	}
+	for name, g := range h.globals {
+		types[name] = g.value_type
+	}
+	return types
+}
+
+// gives r the globals, with overrides in place of the values they were declared with
+func (h *Host) set_globals(r *Runtime, overrides map[string]any) error {
+	for name, value := range overrides {
+		g, declared := h.lookup_global(name)
+		if !declared {
+			return fmt.Errorf("global %s was never declared with SetGlobal", name)
+		}
+		v, err := ToValue(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if v == nil || v.Type() != g.value_type {
+			return fmt.Errorf("global %s is a %v, not a %T", name, g.value_type, value)
+		}
+	}
+	if h == nil {
+		return nil
+	}
+	for name, g := range h.globals {
+		value, overridden := overrides[name]
+		if !overridden {
+			value = g.value
+		}
+		v, err := ToValue(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		r.SetGlobal(name, named(v, name))
+	}
+	return nil
+}
+
+func (h *Host) lookup_global(name string) (host_global, bool) {
+	if h == nil {
+		return host_global{}, false
+	}
+	g, declared := h.globals[name]
+	return g, declared
+}
+
+// the names of every registered function, sorted
+func (h *Host) Names() []string {
+	if h == nil {
+		return []string{}
+	}
+	names := names_of(h.functions)
+	sort.Strings(names)
+	return names
+}
+
+// the function bound to name, nil if there is no host or no such function
+func (h *Host) lookup(name string) *HostFunction {
+	if h == nil {
+		return nil
+	}
+	return h.functions[name]
+}
+
+// true if s would tokenize as a single name that isn't a keyword
+func is_name(s string) bool {
+	lines := Tokenize(s, "", &ErrorCollector{})
+	return len(lines) == 1 && len(lines[0]) == 1 && lines[0][0].TokenType == Name_TType && lines[0][0].text == s
+}
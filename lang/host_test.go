@@ -0,0 +1,199 @@
+package lang
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// the codes of the errors compiling src against h gives, warnings left out
+func error_codes(src string, h *Host) []string {
+	_, diags := CompileReader(strings.NewReader(src), CompileOptions{Host: h})
+	codes := []string{}
+	for _, d := range diags {
+		if d.Severity == ErrorSeverity.String() {
+			codes = append(codes, d.Code)
+		}
+	}
+	return codes
+}
+
+func TestHostArgumentTypes(t *testing.T) {
+	h := NewHost()
+	h.Register("twice", func(a int) int { return a * 2 })
+	h.Register("shout", func(s string) string { return strings.ToUpper(s) })
+	h.Register("anything", func(v Value) Value { return v })
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{`print twice(2)`, []string{}},
+		{`print twice("x")`, []string{"E0205"}},
+		{`print twice(1.5)`, []string{"E0205"}},
+		{`print twice(twice(2))`, []string{}},
+		{`print twice(shout("x"))`, []string{"E0205"}},
+		{`print shout(twice(2))`, []string{"E0205"}},
+		{"var n int = 2\nprint twice(n)", []string{}},
+		{"var s string = \"x\"\nprint twice(s)", []string{"E0205"}},
+		{`print anything("x")`, []string{}},
+		{"func f() int {\n\treturn twice(2)\n}\nprint f()", []string{}},
+		{"func f() string {\n\treturn twice(2)\n}\nprint f()", []string{"E0205"}},
+		{"func f() string {\n\treturn \"x\"\n}\nprint twice(f())", []string{"E0205"}},
+	}
+	for _, test := range tests {
+		if got := error_codes(test.src, h); strings.Join(got, " ") != strings.Join(test.want, " ") {
+			t.Errorf("%q: got %v, want %v", test.src, got, test.want)
+		}
+	}
+}
+
+// a global from the host keeps the type it was given
+func TestHostGlobalTypes(t *testing.T) {
+	h := NewHost()
+	if err := h.SetGlobal("limit", 10); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"limit = 20\nprint limit", []string{}},
+		{"limit = \"lots\"", []string{"E0205"}},
+		{"limit = 2.5", []string{"E0205"}},
+		{"var limit string = \"lots\"", []string{"E0204"}},
+		{"var limit int", []string{"E0204"}},
+	}
+	for _, test := range tests {
+		if got := error_codes(test.src, h); strings.Join(got, " ") != strings.Join(test.want, " ") {
+			t.Errorf("%q: got %v, want %v", test.src, got, test.want)
+		}
+	}
+}
+
+func TestHostPanic(t *testing.T) {
+	h := NewHost()
+	h.Register("boom", func(n int) int { return []int{}[n] })
+	src := "func f(n int) int {\n\treturn boom(n)\n}\nprint f(3)"
+	prog, diags := CompileReader(strings.NewReader(src), CompileOptions{Host: h})
+	if prog == nil {
+		t.Fatal(diags)
+	}
+	for _, vm := range []bool{false, true} {
+		res, err := prog.Run(context.Background(), RunOptions{VM: vm})
+		var re RuntimeError
+		if !errors.As(err, &re) || re.kind != HostFunctionError {
+			t.Fatalf("vm %v: %v", vm, err)
+		}
+		if !strings.Contains(err.Error(), "boom: panicked: runtime error: index out of range") || re.line != 2 {
+			t.Errorf("vm %v: %v", vm, err)
+		}
+		d := res.Diagnostics[len(res.Diagnostics)-1]
+		if d.Code != "E0308" || len(d.Notes) != 1 || d.Notes[0] != "in f called from line 4:7" {
+			t.Errorf("vm %v: %+v", vm, d)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   any
+		err  string //what the error has in it, "" for none
+	}{
+		{"add", func(a, b int) int { return a + b }, ""},
+		{"check", func(s string) error { return nil }, ""},
+		{"sum", func(ns []int) (int, error) { return 0, nil }, ""},
+		{"generic", func(args []Value) (Value, error) { return nil, nil }, ""},
+		{"nothing", func() {}, ""},
+		{"9lives", func() {}, "can't be the name"},
+		{"print", func() {}, "can't be the name"},
+		{"add", func() {}, "already registered"},
+		{"notfunc", 3, "expected a func"},
+		{"nilfunc", (func())(nil), "expected a func"},
+		{"many", func(ns ...int) {}, "variadic"},
+		{"two", func() (int, int) { return 0, 0 }, "one value and an error"},
+		{"chan", func(c chan int) {}, "can't pass"},
+		{"mapout", func() map[int]int { return nil }, "can't be given"},
+	}
+	h := NewHost()
+	for _, test := range tests {
+		err := h.Register(test.name, test.fn)
+		if test.err == "" && err != nil || test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: got %v, want %q", test.name, err, test.err)
+		}
+	}
+	if got := strings.Join(h.Names(), " "); got != "add check generic nothing sum" {
+		t.Errorf("registered %s", got)
+	}
+}
+
+func TestHostCalls(t *testing.T) {
+	h := NewHost()
+	h.Register("add", func(a, b int) int { return a + b })
+	h.Register("sum", func(ns []int) int {
+		total := 0
+		for _, n := range ns {
+			total += n
+		}
+		return total
+	})
+	h.Register("halve", func(n int) (int, error) {
+		if n%2 != 0 {
+			return 0, errors.New("odd")
+		}
+		return n / 2, nil
+	})
+	h.Register("count", func(args []Value) (Value, error) { return &IntType{value: len(args)}, nil })
+	h.Register("pair", func(s string, f float64) []any { return []any{s, f} })
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"print add(1, 2)", "3\n"},
+		{"print add(add(1, 2), 3)", "6\n"},
+		{"print sum((1, 2, 3))", "6\n"},
+		{"print halve(4)", "2\n"},
+		{"print count(1, \"a\", 2.5)", "3\n"},
+		{"print count()", "0\n"},
+		{"print pair(\"a\", 1.5)", "a 1.5\n"},
+		{"func f(n int) int {\n\treturn add(n, n)\n}\nprint f(4)", "8\n"},
+	}
+	for _, test := range tests {
+		prog, diags := CompileReader(strings.NewReader(test.src), CompileOptions{Host: h})
+		if prog == nil {
+			t.Errorf("%q: %v", test.src, diags)
+			continue
+		}
+		for _, vm := range []bool{false, true} {
+			var out strings.Builder
+			if _, err := prog.Run(context.Background(), RunOptions{Output: &out, VM: vm}); err != nil || out.String() != test.want {
+				t.Errorf("%q, vm %v: printed %q, %v, want %q", test.src, vm, out.String(), err, test.want)
+			}
+		}
+	}
+	failing := []struct {
+		src  string
+		code string
+		want string
+	}{
+		{"print halve(3)", "E0308", "halve: odd"},
+		{"print sum((1, \"a\"))", "E0205", "argument 1"},
+		{"print add(1)", "E0206", "add"},
+	}
+	for _, test := range failing {
+		prog, diags := CompileReader(strings.NewReader(test.src), CompileOptions{Host: h})
+		if prog == nil {
+			if len(diags) == 0 || diags[0].Code != test.code {
+				t.Errorf("%q: got %v, want %s", test.src, diags, test.code)
+			}
+			continue
+		}
+		for _, vm := range []bool{false, true} {
+			res, err := prog.Run(context.Background(), RunOptions{VM: vm})
+			if err == nil || res.Diagnostics[0].Code != test.code || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("%q, vm %v: got %v", test.src, vm, err)
+			}
+		}
+	}
+}
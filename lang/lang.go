@@ -0,0 +1,154 @@
+// Package lang is the language as a library, Compile a program once and Run it as many times as needed.
+//
+//	prog, diags := lang.Compile(src)
+//	if prog == nil {
+//		// diags says why
+//	}
+//	res, err := prog.Run(ctx, lang.RunOptions{})
+//
+// Go functions and global variables are given to scripts with a Host
+//
+//	host := lang.NewHost()
+//	host.Register("add", func(a, b int) int { return a + b })
+//	host.SetGlobal("limit", 10)
+//	prog, diags := lang.CompileReader(r, lang.CompileOptions{Host: host})
+//	res, err := prog.Run(ctx, lang.RunOptions{Globals: map[string]any{"limit": 20}})
+//	var total int
+//	err = res.Global("total", &total)
+//
+// RunOptions.VM runs the program as bytecode instead of walking the tree, the output is the same either way.
+//
+// Tokenize, MakeTree and NewRuntime are there too for tools that need the pieces.
+package lang
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// a program that parsed and type checked, Run it as many times as needed
+type Program struct {
+	ast   []ASTNode
+	file  string
+	lines []string
+	host  *Host
+	code  *bytecode
+}
+
+type CompileOptions struct {
+	File           string    //what diagnostics say the source is called, "<main>" if empty
+	LintConfigPath string    //lint rule settings, "" for the defaults
+	Host           *Host     //go functions the program can call, nil if there are none
+	Trace          io.Writer //what the parser is doing, for debugging it, nil for nothing
+}
+
+// compiles src with the default options, prog is nil if there were errors
+// diags has the warnings too, so it isn't always empty when prog isn't nil
+func Compile(src string) (*Program, []Diagnostic) {
+	return CompileReader(strings.NewReader(src), CompileOptions{})
+}
+
+func CompileReader(source io.Reader, opts CompileOptions) (*Program, []Diagnostic) {
+	if opts.File == "" {
+		opts.File = "<main>"
+	}
+	errs := &ErrorCollector{}
+	lexer := NewLexer(source, opts.File, errs)
+	ast, ok := make_tree(lexer, errs, opts)
+	if ok {
+		lint_config := DefaultLintConfig()
+		if opts.LintConfigPath != "" {
+			var err error
+			lint_config, err = LoadLintConfig(opts.LintConfigPath)
+			if err != nil {
+				errs.AddError(err)
+			}
+		}
+		for _, warning := range Lint(ast, lint_config, LintIgnoresFrom(lexer.Comments(), lexer.Source())) {
+			errs.AddError(warning)
+		}
+	}
+	diags := errs.Diagnostics(lexer.Source())
+	sort_diagnostics(diags)
+	if errs.HasErrors() {
+		return nil, diags
+	}
+	return &Program{ast: ast, file: opts.File, lines: lexer.Source(), host: opts.Host, code: compile_program(ast, opts.Host)}, diags
+}
+
+// every documented declaration in the program
+func (p *Program) Docs() []DocEntry {
+	return Docs(p.ast)
+}
+
+// how a program is run, the zero value is fine
+type RunOptions struct {
+	Globals map[string]any //values for globals declared with Host.SetGlobal, in place of the ones given there
+	Limits  Limits
+	Output  io.Writer //where print goes, nil drops it so a program can't write anywhere it wasn't given
+	Trace   io.Writer //each top level statement as it runs, nil for nothing
+	//run the program as bytecode on a stack machine instead of walking the tree, it does the same thing faster
+	//except for solve, option and require, which the vm hands to the tree walker, so a program that is mostly
+	//solving runs about as fast either way, and loops, which look up their variables by name like the tree walker does
+	VM bool
+}
+
+// what a run left behind
+type Result struct {
+	Diagnostics []Diagnostic
+	runtime     *Runtime
+	value       Value //what the last expression evaluated to, kept since searching a solution set for Global evaluates more
+}
+
+// runs the program from the top with fresh globals
+// err is what stopped it, if anything, and is also the last of the diagnostics
+// it stops early with an error once ctx is done or it goes over opts.Limits
+func (p *Program) Run(ctx context.Context, opts RunOptions) (*Result, error) {
+	runtime := NewRuntime(p.ast)
+	runtime.host = p.host
+	runtime.SetLimits(opts.Limits)
+	runtime.SetOutput(opts.Output)
+	if opts.Output == nil {
+		runtime.SetOutput(io.Discard)
+	}
+	runtime.SetTrace(opts.Trace)
+	res := &Result{Diagnostics: []Diagnostic{}, runtime: runtime}
+	if err := p.host.set_globals(runtime, opts.Globals); err != nil {
+		return res, err
+	}
+	var err error
+	if opts.VM {
+		err = runtime.run_compiled(ctx, p.code)
+	} else {
+		err = runtime.RunContext(ctx)
+	}
+	if err != nil {
+		runtime.diagnostics.AddError(err)
+	}
+	res.Diagnostics = runtime.diagnostics.Diagnostics(p.lines)
+	res.value = runtime.LastResult()
+	return res, err
+}
+
+// puts the global called name into what target points at, see FromValue
+// solution sets are searched all the way through first
+func (res *Result) Global(name string, target any) error {
+	v, found := res.runtime.Global(name)
+	if !found {
+		return fmt.Errorf("there is no global %s with a value", name)
+	}
+	if ss, is_solutions := v.(*SolutionSetType); is_solutions {
+		ss.Materialize(res.runtime)
+	}
+	return FromValue(v, target)
+}
+
+// puts what the last expression of the program evaluated to into what target points at
+func (res *Result) Value(target any) error {
+	if res.value == nil {
+		return fmt.Errorf("the program did not end with a value")
+	}
+	return FromValue(res.value, target)
+}
@@ -0,0 +1,184 @@
+package lang
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// compiles src and runs it with the tree walker or the vm, what it printed and what stopped it
+func run_source(t *testing.T, src string, vm bool) (string, error) {
+	t.Helper()
+	prog, diags := CompileReader(strings.NewReader(src), CompileOptions{File: "test"})
+	if prog == nil {
+		t.Fatalf("%q didn't compile: %v", src, diags)
+	}
+	var out bytes.Buffer
+	_, err := prog.Run(context.Background(), RunOptions{Output: &out, VM: vm})
+	return out.String(), err
+}
+
+// what src prints, failing the test if it stops with an error or the vm prints something else
+func output_of(t *testing.T, src string) string {
+	t.Helper()
+	walked, err := run_source(t, src, false)
+	if err != nil {
+		t.Fatalf("%q: %v", src, err)
+	}
+	compiled, err := run_source(t, src, true)
+	if err != nil {
+		t.Fatalf("%q with the vm: %v", src, err)
+	}
+	if walked != compiled {
+		t.Errorf("%q: the vm printed %q, the tree walker %q", src, compiled, walked)
+	}
+	return walked
+}
+
+// the sample programs at the top of the repo have to keep up with the language
+func TestSamples(t *testing.T) {
+	files, err := filepath.Glob("../*.lang")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no samples: %v", err)
+	}
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		output_of(t, string(src))
+	}
+}
+
+func TestPrint(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"print 1", "1\n"},
+		{"print 2.5", "2.5\n"},
+		{"print \"a\\tb\"", "a\tb\n"},
+		{"print true", "true\n"},
+		{"var n int\nprint n", "nothing\n"},
+		{"print (1, \"two\", 3.5)", "1 two 3.5\n"},
+		{"print (1, (2, 3), true)", "1 <2 3> true\n"},
+		{"var x int in 0..2\nvar u universe = (x)\nsolve u, s {\n}\nprint s", "{x: 0}\n{x: 1}\n{x: 2}\n"},
+		{"var x int in 0..2\nvar u universe = (x)\nsolve u, s {\n}\nprint s[1]", "{x: 1}\n"},
+	}
+	for _, test := range tests {
+		if got := output_of(t, test.src); got != test.want {
+			t.Errorf("%q: printed %q, want %q", test.src, got, test.want)
+		}
+	}
+}
+
+// each run prints to its own Output, even at the same time, and without one it prints nowhere
+func TestOutput(t *testing.T) {
+	prog, diags := Compile("var x int in 0..99\nvar u universe = (x)\nsolve u, s {\n}\nfor a in s {\n\tprint a.x\n}")
+	if prog == nil {
+		t.Fatal(diags)
+	}
+	outs := make([]bytes.Buffer, 8)
+	done := make(chan error)
+	for i := range outs {
+		go func(out *bytes.Buffer, vm bool) {
+			_, err := prog.Run(context.Background(), RunOptions{Output: out, VM: vm})
+			done <- err
+		}(&outs[i], i%2 == 0)
+	}
+	for range outs {
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range outs {
+		if lines := strings.Count(outs[i].String(), "\n"); lines != 100 || !strings.HasSuffix(outs[i].String(), "\n98\n99\n") {
+			t.Errorf("run %d printed %d lines", i, lines)
+		}
+	}
+
+	read, write, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = write
+	_, err = prog.Run(context.Background(), RunOptions{})
+	os.Stdout = stdout
+	write.Close()
+	leaked, _ := io.ReadAll(read)
+	if err != nil || len(leaked) > 0 {
+		t.Errorf("with no Output it printed %q, %v", leaked, err)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	//a var with a value is two statements, declaring it and setting it
+	prog, _ := Compile("var a int = 1\nprint a")
+	var trace bytes.Buffer
+	if _, err := prog.Run(context.Background(), RunOptions{Trace: &trace}); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(trace.String()), "\n"); len(lines) != 3 || !strings.HasPrefix(lines[2], "line 2:") {
+		t.Errorf("traced %q", trace.String())
+	}
+}
+
+func TestGlobals(t *testing.T) {
+	h := NewHost()
+	h.SetGlobal("limit", 10)
+	h.SetGlobal("name", "x")
+	prog, diags := CompileReader(strings.NewReader("var total int = limit * 2\nvar x int in 0..3\nvar u universe = (x)\nsolve u, s {\n\trequire x < limit - 8\n}\nvar greeting string = \"hi \" + name"), CompileOptions{Host: h})
+	if prog == nil {
+		t.Fatal(diags)
+	}
+	type answer struct {
+		X int `lang:"x"`
+	}
+	tests := []struct {
+		globals map[string]any
+		total   int
+		answers []answer
+		value   string
+	}{
+		{nil, 20, []answer{{0}, {1}}, "hi x"},
+		{map[string]any{"limit": 11}, 22, []answer{{0}, {1}, {2}}, "hi x"},
+		{map[string]any{"name": "y", "limit": 9}, 18, []answer{{0}}, "hi y"},
+	}
+	for _, test := range tests {
+		res, err := prog.Run(context.Background(), RunOptions{Globals: test.globals})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var total int
+		var answers []answer
+		var value string
+		if err := res.Global("total", &total); err != nil || total != test.total {
+			t.Errorf("%v: total is %d, %v", test.globals, total, err)
+		}
+		if err := res.Global("s", &answers); err != nil || !reflect.DeepEqual(answers, test.answers) {
+			t.Errorf("%v: answers are %v, %v", test.globals, answers, err)
+		}
+		if err := res.Value(&value); err != nil || value != test.value {
+			t.Errorf("%v: value is %q, %v", test.globals, value, err)
+		}
+	}
+	for _, bad := range []map[string]any{{"limit": "ten"}, {"undeclared": 1}, {"limit": nil}} {
+		if _, err := prog.Run(context.Background(), RunOptions{Globals: bad}); err == nil {
+			t.Errorf("%v: no error", bad)
+		}
+	}
+	res, _ := prog.Run(context.Background(), RunOptions{})
+	var wrong string
+	if err := res.Global("total", &wrong); err == nil {
+		t.Error("an int went into a string")
+	}
+	if err := res.Global("nope", &wrong); err == nil {
+		t.Error("a global that isn't there was found")
+	}
+}
@@ -0,0 +1,145 @@
+package lang
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// tokenizes source as it is read, a line at a time, so the whole file never has to be in memory as tokens
+// use either NextLine or Next/Peek on one lexer, not both
+type Lexer struct {
+	reader   *bufio.Reader
+	file     string
+	errs     *ErrorCollector
+	line_num int
+	lines    []string //every line read so far, for showing errors
+	done     bool
+
+	open_raw      *Token //a raw string still going at the end of the last line
+	comment_depth int
+	comment_start Token //where the outermost open /* was
+
+	buffer   []Token //what is left of the current line for Next and Peek
+	comments []Token //every comment so far, the parser throws them away but lint wants them
+}
+
+func NewLexer(r io.Reader, file string, errs *ErrorCollector) *Lexer {
+	return &Lexer{
+		reader: bufio.NewReader(r),
+		file:   file,
+		errs:   errs,
+	}
+}
+
+func (lx *Lexer) Comments() []Token {
+	return lx.comments
+}
+
+// the source lines read so far, without their line endings
+func (lx *Lexer) Source() []string {
+	return lx.lines
+}
+
+// one line without its \n or \r\n, ok is false once there is nothing left
+func (lx *Lexer) read_line() (string, bool) {
+	line, err := lx.reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		lx.errs.AddError(CodedError{"E0902", fmt.Errorf("%s: could not read source: %w", lx.file, err)})
+		lx.errs.ShouldStop()
+		return "", false
+	}
+	if err == io.EOF && line == "" {
+		return "", false
+	}
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+	lx.line_num++
+	lx.lines = append(lx.lines, line)
+	return line, true
+}
+
+// the tokens of the next line, a raw string going over many lines makes them all one line
+// ok is false at the end of the source
+func (lx *Lexer) NextLine() ([]Token, bool) {
+	if lx.done {
+		return nil, false
+	}
+	toks := []Token{}
+	read_any := false
+	for {
+		line, ok := lx.read_line()
+		if !ok {
+			break
+		}
+		read_any = true
+		lt := LineTokenizer{
+			line_src: line,
+			index:    0,
+			line_num: lx.line_num,
+			file:     lx.file,
+			errs:     lx.errs,
+			open_raw: lx.open_raw,
+
+			comment_depth: lx.comment_depth,
+		}
+		line_toks := lt.Parse()
+		if lx.comment_depth == 0 && lt.comment_depth > 0 {
+			for _, t := range line_toks {
+				if t.TokenType == Comment_TType && strings.HasPrefix(t.text, "/*") {
+					lx.comment_start = t
+				}
+			}
+		}
+		lx.comment_depth = lt.comment_depth
+		for _, t := range line_toks {
+			if t.TokenType == Comment_TType {
+				lx.comments = append(lx.comments, t)
+			}
+		}
+		lx.open_raw = lt.open_raw
+		//the rest of a statement with a multi line string in it belongs to the line it started on
+		toks = append(toks, line_toks...)
+		if lx.open_raw == nil {
+			return toks, true
+		}
+	}
+	lx.done = true
+	if lx.comment_depth > 0 {
+		le := NewLocatedError("E0002", lx.comment_start.line, lx.comment_start.index_start, "block comment is missing its closing `*/`")
+		le.file = lx.file
+		lx.errs.AddError(le)
+	}
+	if lx.open_raw != nil {
+		le := NewLocatedError("E0002", lx.open_raw.line, lx.open_raw.index_start, "raw string is missing its closing \"`\"")
+		le.file = lx.file
+		lx.errs.AddError(le)
+		//still give it to the parser so it doesn't complain about a missing expression too
+		lx.open_raw.last_line = lx.line_num
+		toks = append(toks, *lx.open_raw)
+		lx.open_raw = nil
+	}
+	return toks, read_any
+}
+
+// the next token without taking it, ok is false at the end of the source
+func (lx *Lexer) Peek() (Token, bool) {
+	for len(lx.buffer) == 0 {
+		toks, ok := lx.NextLine()
+		if !ok {
+			return Token{}, false
+		}
+		lx.buffer = toks
+	}
+	return lx.buffer[0], true
+}
+
+// takes the next token, going on to the next line when this one runs out
+func (lx *Lexer) Next() (Token, bool) {
+	tok, ok := lx.Peek()
+	if ok {
+		lx.buffer = lx.buffer[1:]
+	}
+	return tok, ok
+}
@@ -0,0 +1,79 @@
+package lang
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// reading a byte at a time has to give the same tokens as having the whole source at once
+func TestLexerReadsInPieces(t *testing.T) {
+	sources := []string{
+		"var a int = 1\nprint a",
+		"print `one\ntwo\nthree` + \"x\"\nprint 1",
+		"a /* b\nc */ d\n/// docs\nvar e int",
+		"windows\r\nline endings\r\n",
+		"print \"é😀\"\n\n\nprint 2",
+		"no newline at the end",
+		"",
+	}
+	for _, src := range sources {
+		whole := Tokenize(src, "test", &ErrorCollector{})
+		lx := NewLexer(iotest.OneByteReader(strings.NewReader(src)), "test", &ErrorCollector{})
+		pieces := [][]Token{}
+		for {
+			toks, ok := lx.NextLine()
+			if !ok {
+				break
+			}
+			pieces = append(pieces, toks)
+		}
+		if !reflect.DeepEqual(whole, pieces) {
+			t.Errorf("%q: got %v, want %v", src, pieces, whole)
+		}
+	}
+}
+
+func TestLexerNextAndPeek(t *testing.T) {
+	lx := NewLexer(strings.NewReader("a b\n\nc // d\r\n"), "test", &ErrorCollector{})
+	got := []string{}
+	for {
+		peeked, ok := lx.Peek()
+		tok, next_ok := lx.Next()
+		if ok != next_ok || peeked != tok {
+			t.Fatalf("peeked %v, %v then got %v, %v", peeked, ok, tok, next_ok)
+		}
+		if !ok {
+			break
+		}
+		got = append(got, tok.text)
+	}
+	if want := []string{"a", "b", "c", " d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if want := []string{"a b", "", "c // d"}; !reflect.DeepEqual(lx.Source(), want) {
+		t.Errorf("source %q, want %q", lx.Source(), want)
+	}
+	if len(lx.Comments()) != 1 || lx.Comments()[0].line != 3 {
+		t.Errorf("comments %v", lx.Comments())
+	}
+}
+
+func TestLexerReadError(t *testing.T) {
+	errs := &ErrorCollector{}
+	lx := NewLexer(iotest.ErrReader(errors.New("disk on fire")), "test", errs)
+	if toks, ok := lx.NextLine(); ok {
+		t.Errorf("got %v from a reader that fails", toks)
+	}
+	d := errs.Diagnostics(nil)
+	if len(d) != 1 || d[0].Code != "E0902" || !strings.Contains(d[0].Message, "disk on fire") {
+		t.Errorf("got %v", d)
+	}
+	//and compiling it says so instead of saying the program is empty
+	_, diags := CompileReader(iotest.ErrReader(errors.New("disk on fire")), CompileOptions{})
+	if len(diags) != 1 || diags[0].Code != "E0902" {
+		t.Errorf("compiling got %v", diags)
+	}
+}
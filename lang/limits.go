@@ -0,0 +1,77 @@
+package lang
+
+import (
+	"context"
+	"fmt"
+)
+
+// bounds on how much a program can do, so a script can't hang or eat the program embedding it
+// 0 means no limit, apart from CallDepth
+type Limits struct {
+	Steps     int //statements run plus branches a solve block tries
+	CallDepth int //how many calls can be running at once, 0 is DefaultCallDepth
+	MaxLength int //longest string, tuple or solution set a program can make
+}
+
+// how deep calls can go when Limits.CallDepth isn't set, there is always a limit since recursing without one
+// runs out of go stack, which kills the whole process instead of just the script
+// a higher CallDepth can be set, but the go stack still runs out somewhere in the hundreds of thousands
+const DefaultCallDepth = 10000
+
+func (r *Runtime) SetLimits(limits Limits) {
+	r.limits = limits
+}
+
+// counts one step of the program, false once it has to stop because ctx is done or it ran out of steps
+func (r *Runtime) step(span Span) bool {
+	if r.last_error != nil {
+		return false
+	}
+	r.steps++
+	if r.limits.Steps > 0 && r.steps > r.limits.Steps {
+		r.throwError(StepLimitError, span, fmt.Sprintf("ran out of steps after %d", r.limits.Steps))
+		return false
+	}
+	if err := r.ctx.Err(); err != nil {
+		r.throwErrorCause(CancelledError, span, fmt.Sprintf("stopped by the host: %v", err), err)
+		return false
+	}
+	return true
+}
+
+// false, with an error, if calling one more function would go over the call depth
+func (r *Runtime) can_call(name string, span Span) bool {
+	depth := r.limits.CallDepth
+	if depth <= 0 {
+		depth = DefaultCallDepth
+	}
+	if len(r.call_stack) >= depth {
+		r.throwError(CallDepthError, span, fmt.Sprintf("calling %s would go deeper than %d calls", name, depth))
+		return false
+	}
+	return true
+}
+
+// false, with an error, if what was just made is longer than MaxLength
+func (r *Runtime) check_length(v Value, span Span) bool {
+	if r.limits.MaxLength <= 0 {
+		return true
+	}
+	length := 0
+	switch val := v.(type) {
+	case *StringType:
+		length = len(val.value)
+	case *TupleType:
+		length = len(val.values)
+	case *SolutionSetType:
+		length = len(val.solutions)
+	}
+	if length > r.limits.MaxLength {
+		r.throwError(LengthLimitError, span, fmt.Sprintf("a %v of length %d is longer than the limit of %d", v.Type(), length, r.limits.MaxLength))
+		return false
+	}
+	return true
+}
+
+// the context a runtime made without one uses, it is never done
+var no_context = context.Background()
@@ -0,0 +1,89 @@
+package lang
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// the kind of error running src with limits stops with, on the tree walker and the vm
+func limit_errors(t *testing.T, ctx context.Context, src string, limits Limits) map[bool]error {
+	t.Helper()
+	prog, diags := Compile(src)
+	if prog == nil {
+		t.Fatalf("%q didn't compile: %v", src, diags)
+	}
+	errs := map[bool]error{}
+	for _, vm := range []bool{false, true} {
+		_, errs[vm] = prog.Run(ctx, RunOptions{Limits: limits, VM: vm})
+	}
+	return errs
+}
+
+func TestLimits(t *testing.T) {
+	recurse := "func down(n int) int {\n\treturn down(n - 1)\n}\nprint down(0)"
+	search := "var x int in 0..9\nvar y int in 0..9\nvar u universe = (x, y)\nsolve u, s {\n\trequire x * y == 81\n}\nprint s"
+	tests := []struct {
+		name   string
+		src    string
+		limits Limits
+		want   RuntimeErrorKind
+	}{
+		{"steps", recurse, Limits{Steps: 100}, StepLimitError},
+		{"solve steps", search, Limits{Steps: 20}, StepLimitError},
+		{"call depth", recurse, Limits{CallDepth: 50}, CallDepthError},
+		//without a depth there is still the default, a script recursing forever doesn't take the go stack down with it
+		{"default call depth", recurse, Limits{}, CallDepthError},
+		{"string length", "var s string = \"ab\"\ns = s + s\ns = s + s\nprint s", Limits{MaxLength: 5}, LengthLimitError},
+		{"tuple length", "print (1, 2, 3, 4)", Limits{MaxLength: 3}, LengthLimitError},
+		{"solution set length", "var x int in 0..9\nvar u universe = (x)\nsolve u, s {\n}\nprint s", Limits{MaxLength: 3}, LengthLimitError},
+		{"limit keeps it short", "var x int in 0..9\nvar u universe = (x)\nsolve u, s limit 3 {\n}\nprint s", Limits{MaxLength: 3}, -1},
+		{"within every limit", "print (1, 2, 3)", Limits{Steps: 10, CallDepth: 1, MaxLength: 3}, -1},
+	}
+	for _, test := range tests {
+		for vm, err := range limit_errors(t, context.Background(), test.src, test.limits) {
+			var re RuntimeError
+			if test.want == -1 {
+				if err != nil {
+					t.Errorf("%s, vm %v: %v", test.name, vm, err)
+				}
+			} else if !errors.As(err, &re) || re.kind != test.want {
+				t.Errorf("%s, vm %v: got %v, want a %s", test.name, vm, err, test.want.Code())
+			}
+		}
+	}
+}
+
+func TestCancel(t *testing.T) {
+	//far too many branches to get through, only the context stops it
+	forever := "var x int in 0..999\nvar y int in 0..999\nvar z int in 0..999\nvar u universe = (x, y, z)\nsolve u, s {\n\trequire x * y * z == -1\n}\nprint s"
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	for vm, err := range limit_errors(t, cancelled, forever, Limits{}) {
+		var re RuntimeError
+		if !errors.Is(err, context.Canceled) || !errors.As(err, &re) || re.kind != CancelledError {
+			t.Errorf("cancelled, vm %v: %v", vm, err)
+		}
+	}
+	timeout, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	for vm, err := range limit_errors(t, timeout, forever, Limits{}) {
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("timeout, vm %v: %v", vm, err)
+		}
+	}
+	if took := time.Since(start); took > 5*time.Second {
+		t.Errorf("took %v to notice the timeout", took)
+	}
+	//a loop in bytecode has to check too, not just statements the tree walker runs
+	loop := "var x int in 0..999999\nvar u universe = (x)\nsolve u, s {\n}\nvar total int = 0\nfor a in s {\n\ttotal = total + a.x\n}\nprint total"
+	cancelled, cancel = context.WithCancel(context.Background())
+	cancel()
+	for vm, err := range limit_errors(t, cancelled, loop, Limits{}) {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("loop, vm %v: %v", vm, err)
+		}
+	}
+}
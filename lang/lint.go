@@ -0,0 +1,453 @@
+package lang
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// every lint rule, by the name config files and lint:ignore comments use
+var lint_rules = map[string]string{
+	"unused-variable":          "a variable that is never read",
+	"never-set":                "a variable that is read but never given a value",
+	"shadowing":                "a variable in a solve or for block with the same name as one outside it",
+	"unreachable":              "code after a return that can never run",
+	"unused-universe-variable": "a universe variable that nothing in the solve block constrains",
+}
+
+// the stable code each rule is reported with
+var lint_codes = map[string]string{
+	"unused-variable":          "W0101",
+	"never-set":                "W0102",
+	"shadowing":                "W0103",
+	"unreachable":              "W0104",
+	"unused-universe-variable": "W0105",
+}
+
+// how loud each rule is, rules not in severity are warnings
+type LintConfig struct {
+	severity map[string]Severity
+	off      map[string]bool
+}
+
+func DefaultLintConfig() LintConfig {
+	return LintConfig{severity: map[string]Severity{}, off: map[string]bool{}}
+}
+
+// reads a config file of lines like
+//
+//	unused-variable off
+//	shadowing error
+//
+// the levels are off, info, warning and error, // starts a comment
+func LoadLintConfig(path string) (LintConfig, error) {
+	config := DefaultLintConfig()
+	f, err := os.Open(path)
+	if err != nil {
+		return config, CodedError{"E0903", err}
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	line_num := 0
+	for scanner.Scan() {
+		line_num++
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return config, CodedError{"E0903", fmt.Errorf("%s:%d: expected a rule name and a level", path, line_num)}
+		}
+		rule, level := fields[0], fields[1]
+		if _, exists := lint_rules[rule]; !exists {
+			suggestion := ""
+			if closest, found := closest_name(rule, names_of(lint_rules)); found {
+				suggestion = fmt.Sprintf(", did you mean `%s`?", closest)
+			}
+			return config, CodedError{"E0903", fmt.Errorf("%s:%d: unknown lint rule %s%s", path, line_num, rule, suggestion)}
+		}
+		switch level {
+		case "off":
+			config.off[rule] = true
+		case "info":
+			config.severity[rule] = InfoSeverity
+		case "warning":
+			config.severity[rule] = WarningSeverity
+		case "error":
+			config.severity[rule] = ErrorSeverity
+		default:
+			return config, CodedError{"E0903", fmt.Errorf("%s:%d: unknown level %s, expected off, info, warning or error", path, line_num, level)}
+		}
+	}
+	return config, scanner.Err()
+}
+
+// the rules turned off by // lint:ignore comments, by line. an empty list means every rule
+type LintIgnores map[int][]string
+
+// a lint:ignore comment covers its own line, and the next one if it is on a line by itself
+func LintIgnoresFrom(comments []Token, lines []string) LintIgnores {
+	ignores := LintIgnores{}
+	for _, c := range comments {
+		text := strings.TrimSpace(c.text)
+		if !strings.HasPrefix(text, "lint:ignore") {
+			continue
+		}
+		rules := strings.Fields(strings.TrimPrefix(text, "lint:ignore"))
+		ignores[c.line] = append(ignores[c.line], rules...)
+		if strings.TrimSpace(source_line(lines, c.line)[:c.index_start]) == "" {
+			ignores[c.line+1] = append(ignores[c.line+1], rules...)
+		}
+	}
+	return ignores
+}
+
+func (li LintIgnores) ignored(rule string, line int) bool {
+	rules, has := li[line]
+	if !has {
+		return false
+	}
+	if len(rules) == 0 {
+		return true
+	}
+	for _, r := range rules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+type lint_variable struct {
+	name      string
+	span      Span
+	used, set bool
+}
+
+type lint_scope struct {
+	variables []*lint_variable
+	local     bool //a solve or for block, declaring something already outside it is shadowing
+}
+
+type linter struct {
+	config    LintConfig
+	ignores   LintIgnores
+	found     []LocatedError
+	scopes    []*lint_scope
+	universes map[string]*UniverseLiteral //universe variables set straight from a literal
+}
+
+// checks a parsed program for things that are allowed but probably mistakes
+func Lint(program []ASTNode, config LintConfig, ignores LintIgnores) []LocatedError {
+	l := &linter{config: config, ignores: ignores, universes: map[string]*UniverseLiteral{}}
+	l.push(false)
+	l.statements(program)
+	l.pop()
+	sort.SliceStable(l.found, func(i, j int) bool {
+		if l.found[i].line != l.found[j].line {
+			return l.found[i].line < l.found[j].line
+		}
+		return l.found[i].index < l.found[j].index
+	})
+	return l.found
+}
+
+func (l *linter) report(rule string, span Span, msg string) {
+	if l.config.off[rule] || l.ignores.ignored(rule, span.line) {
+		return
+	}
+	severity, set := l.config.severity[rule]
+	if !set {
+		severity = WarningSeverity
+	}
+	//the rule name is what configs and lint:ignore need, so it goes in the message too
+	le := span.Located(lint_codes[rule], fmt.Sprintf("%s (%s)", msg, rule))
+	le.severity = severity
+	l.found = append(l.found, le)
+}
+
+func (l *linter) push(local bool) {
+	l.scopes = append(l.scopes, &lint_scope{local: local})
+}
+
+// reports what was never used or set in the innermost scope, then drops it
+func (l *linter) pop() {
+	scope := l.scopes[len(l.scopes)-1]
+	l.scopes = l.scopes[:len(l.scopes)-1]
+	for _, v := range scope.variables {
+		if !v.used {
+			l.report("unused-variable", v.span, fmt.Sprintf("%s is declared but never used", v.name))
+		} else if !v.set {
+			l.report("never-set", v.span, fmt.Sprintf("%s is used but never given a value", v.name))
+		}
+	}
+}
+
+func (l *linter) lookup(name string) *lint_variable {
+	for i := len(l.scopes) - 1; i >= 0; i-- {
+		for _, v := range l.scopes[i].variables {
+			if v.name == name {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+func (l *linter) declare(name string, span Span) *lint_variable {
+	top := l.scopes[len(l.scopes)-1]
+	for _, v := range top.variables {
+		if v.name == name {
+			//declared again in the same scope, it is the same variable
+			return v
+		}
+	}
+	if top.local {
+		if outer := l.lookup(name); outer != nil {
+			l.report("shadowing", span, fmt.Sprintf("%s hides the %s declared on line %d", name, name, outer.span.line))
+		}
+	}
+	v := &lint_variable{name: name, span: span}
+	top.variables = append(top.variables, v)
+	return v
+}
+
+func (l *linter) use(name string) {
+	if v := l.lookup(name); v != nil {
+		v.used = true
+	}
+}
+
+func (l *linter) statements(nodes []ASTNode) {
+	returned := false
+	for _, node := range nodes {
+		if returned {
+			l.report("unreachable", node.Span(), "this can never run, it is after a return")
+			returned = false //only say it once per block
+		}
+		l.node(node)
+		if _, is_return := node.(*ReturnNode); is_return {
+			returned = true
+		}
+	}
+}
+
+func (l *linter) node(n ASTNode) {
+	switch node := n.(type) {
+	case *DeclareNode:
+		v := l.declare(node.name, node.span)
+		if node.low != nil {
+			//ranged ints are given values by solve
+			l.node(node.low)
+			l.node(node.high)
+			v.set = true
+		}
+	case *SetNode:
+		l.node(node.from)
+		if v := l.lookup(node.to); v != nil {
+			v.set = true
+		}
+		if ul, is_universe := node.from.(*UniverseLiteral); is_universe {
+			l.universes[node.to] = ul
+		}
+	case *GetNode:
+		l.use(node.name)
+	case *UniverseLiteral:
+		//anything in a universe is an unknown solve gives a value to
+		for _, g := range universe_variables(node.statements) {
+			if v := l.lookup(g.name); v != nil {
+				v.used = true
+				v.set = true
+			}
+		}
+	case *BinaryOpNode:
+		l.node(node.left)
+		l.node(node.right)
+	case *NegateNode:
+		l.node(node.operand)
+	case *AddAnyNode:
+		l.node(node.left)
+		l.node(node.right)
+	case *IndexNode:
+		l.node(node.target)
+		l.node(node.index)
+	case *PropertyNode:
+		l.node(node.target)
+	case *TupleLiteral:
+		for _, v := range node.values {
+			l.node(v)
+		}
+	case *PrintStatement:
+		l.node(node.argument)
+	case *ReturnNode:
+		if node.value != nil {
+			l.node(node.value)
+		}
+	case *CallNode:
+		for _, a := range node.args {
+			l.node(a)
+		}
+	case *RequireNode:
+		l.node(node.condition)
+	case *BlockNode:
+		l.statements(node.lines)
+	case *OptionNode:
+		for _, alternative := range node.alternatives {
+			l.statements(alternative.lines)
+		}
+	case *FunctionDefinition:
+		//function bodies see the globals around them, functions are only ever at the top level
+		l.push(false)
+		for _, p := range node.parameterNames {
+			v := l.declare(p, node.span)
+			v.used, v.set = true, true //an unused parameter is still part of how the function is called
+		}
+		l.statements(node.lines)
+		l.pop()
+	case *SolveNode:
+		l.solve(node)
+	case *ForInNode:
+		l.node(node.set)
+		l.push(true)
+		v := l.declare(node.variable, node.span)
+		v.set = true
+		l.statements(node.body.lines)
+		l.pop()
+	}
+}
+
+func (l *linter) solve(sn *SolveNode) {
+	l.use(sn.universe)
+	if sn.limit != nil {
+		l.node(sn.limit)
+	}
+	if sn.objective != nil {
+		l.node(sn.objective)
+	}
+	results := l.declare(sn.results, sn.span)
+	results.set = true
+	l.push(true)
+	l.statements(sn.body.lines)
+	l.pop()
+
+	ul, known := l.universes[sn.universe]
+	if !known {
+		return
+	}
+	constrained := mentioned_names([]ASTNode{sn.body, sn.objective})
+	for _, s := range ul.statements {
+		if _, bare := s.(*GetNode); !bare {
+			//an expression in the universe itself relates its variables
+			for name := range mentioned_names([]ASTNode{s}) {
+				constrained[name] = true
+			}
+		}
+	}
+	for _, g := range universe_variables(ul.statements) {
+		if !constrained[g.name] {
+			l.report("unused-universe-variable", g.span, fmt.Sprintf("nothing in the solve on line %d constrains %s, every value of it is a solution", sn.span.line, g.name))
+		}
+	}
+}
+
+// every variable name read anywhere in nodes, nil nodes are skipped
+func mentioned_names(nodes []ASTNode) map[string]bool {
+	names := map[string]bool{}
+	var walk func(n ASTNode)
+	walk = func(n ASTNode) {
+		if g, is_get := n.(*GetNode); is_get {
+			names[g.name] = true
+		}
+		for _, child := range ast_children(n) {
+			walk(child)
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+	return names
+}
+
+// every variable name set, declared or looped over anywhere in nodes
+func assigned_names(nodes []ASTNode) map[string]bool {
+	names := map[string]bool{}
+	var walk func(n ASTNode)
+	walk = func(n ASTNode) {
+		switch node := n.(type) {
+		case *SetNode:
+			names[node.to] = true
+		case *DeclareNode:
+			names[node.name] = true
+		case *ForInNode:
+			names[node.variable] = true
+		case *SolveNode:
+			names[node.results] = true
+		}
+		for _, child := range ast_children(n) {
+			walk(child)
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+	return names
+}
+
+// the nodes directly inside n, not counting function bodies since they are separate
+func ast_children(n ASTNode) []ASTNode {
+	switch node := n.(type) {
+	case *DeclareNode:
+		if node.low != nil {
+			return []ASTNode{node.low, node.high}
+		}
+	case *SetNode:
+		return []ASTNode{node.from}
+	case *BinaryOpNode:
+		return []ASTNode{node.left, node.right}
+	case *NegateNode:
+		return []ASTNode{node.operand}
+	case *AddAnyNode:
+		return []ASTNode{node.left, node.right}
+	case *IndexNode:
+		return []ASTNode{node.target, node.index}
+	case *PropertyNode:
+		return []ASTNode{node.target}
+	case *TupleLiteral:
+		return node.values
+	case *UniverseLiteral:
+		return node.statements
+	case *PrintStatement:
+		return []ASTNode{node.argument}
+	case *ReturnNode:
+		if node.value != nil {
+			return []ASTNode{node.value}
+		}
+	case *CallNode:
+		return node.args
+	case *RequireNode:
+		return []ASTNode{node.condition}
+	case *BlockNode:
+		return node.lines
+	case *OptionNode:
+		children := []ASTNode{}
+		for _, alternative := range node.alternatives {
+			children = append(children, alternative)
+		}
+		return children
+	case *SolveNode:
+		children := []ASTNode{node.body}
+		if node.limit != nil {
+			children = append(children, node.limit)
+		}
+		if node.objective != nil {
+			children = append(children, node.objective)
+		}
+		return children
+	case *ForInNode:
+		return []ASTNode{node.set, node.body}
+	}
+	return nil
+}
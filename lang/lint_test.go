@@ -0,0 +1,111 @@
+package lang
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// the severity and code of everything compiling src with the lint config in config reports, "" for the defaults
+func lint_results(t *testing.T, src string, config string) []string {
+	t.Helper()
+	opts := CompileOptions{}
+	if config != "" {
+		opts.LintConfigPath = write_config(t, config)
+	}
+	_, diags := CompileReader(strings.NewReader(src), opts)
+	found := []string{}
+	for _, d := range diags {
+		found = append(found, d.Severity+" "+d.Code)
+	}
+	return found
+}
+
+func TestLintRules(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"var a int = 1\nprint a", []string{}},
+		{"var a int = 1", []string{"warning W0101"}},
+		{"var a int\nprint a", []string{"warning W0102"}},
+		{"func f(unused int) {\n}\nf(1)", []string{}},
+		{"var x int in 0..3\nvar u universe = (x)\nsolve u, s {\n\tvar x int = 1\n\trequire x > 0\n}\nprint s", []string{"warning W0103"}},
+		{"var x int in 0..3\nvar u universe = (x)\nsolve u, s {\n}\nfor x in s {\n\tprint x\n}", []string{"warning W0105", "warning W0103"}},
+		{"func f() int {\n\treturn 1\n\tprint 2\n}\nprint f()", []string{"warning W0104"}},
+		{"var x int in 0..3\nvar y int in 0..3\nvar u universe = (x, y)\nsolve u, s {\n\trequire x > 1\n}\nprint s", []string{"warning W0105"}},
+		{"var x int in 0..3\nvar y int in 0..3\nvar u universe = (x, y)\nsolve u, s minimize y {\n\trequire x > 1\n}\nprint s", []string{}},
+		//lint only runs on programs that compile, an unused variable next to an error isn't worth mentioning
+		{"var a int = 1\nprint b", []string{"error E0201"}},
+	}
+	for _, test := range tests {
+		if got := lint_results(t, test.src, ""); strings.Join(got, ", ") != strings.Join(test.want, ", ") {
+			t.Errorf("%q: got %v, want %v", test.src, got, test.want)
+		}
+	}
+}
+
+func TestLintConfig(t *testing.T) {
+	src := "var a int = 1\nvar b int\nprint b"
+	tests := []struct {
+		config string
+		want   []string
+	}{
+		{"", []string{"warning W0101", "warning W0102"}},
+		{"unused-variable off", []string{"warning W0102"}},
+		{"unused-variable off\nnever-set off", []string{}},
+		{"// just a comment\n\nnever-set info // quietly", []string{"warning W0101", "info W0102"}},
+		{"unused-variable error", []string{"error W0101", "warning W0102"}},
+		{"unused-varaible off", []string{"warning W0101", "warning W0102", "error E0903"}},
+		{"unused-variable loud", []string{"warning W0101", "warning W0102", "error E0903"}},
+		{"unused-variable", []string{"warning W0101", "warning W0102", "error E0903"}},
+	}
+	for _, test := range tests {
+		if got := lint_results(t, src, test.config); strings.Join(got, ", ") != strings.Join(test.want, ", ") {
+			t.Errorf("%q: got %v, want %v", test.config, got, test.want)
+		}
+	}
+	//a rule turned up to an error stops the program compiling
+	prog, _ := CompileReader(strings.NewReader(src), CompileOptions{LintConfigPath: write_config(t, "unused-variable error")})
+	if prog != nil {
+		t.Error("compiled with a lint error")
+	}
+	_, err := LoadLintConfig(write_config(t, "unused-varaible off"))
+	if err == nil || !strings.Contains(err.Error(), "did you mean `unused-variable`?") {
+		t.Errorf("got %v", err)
+	}
+	if _, err := LoadLintConfig(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("no error for a config that isn't there")
+	}
+}
+
+func write_config(t *testing.T, config string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".langlint")
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLintIgnore(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"var a int = 1 // lint:ignore", []string{}},
+		{"var a int = 1 // lint:ignore unused-variable", []string{}},
+		{"var a int = 1 // lint:ignore never-set", []string{"warning W0101"}},
+		{"// lint:ignore unused-variable\nvar a int = 1", []string{}},
+		{"// lint:ignore unused-variable never-set\nvar a int = 1\nvar b int = 1", []string{"warning W0101"}},
+		//a comment after code only covers its own line
+		{"print 1 // lint:ignore\nvar a int = 1", []string{"warning W0101"}},
+		{"var a int = 1 // not lint:ignore", []string{"warning W0101"}},
+	}
+	for _, test := range tests {
+		if got := lint_results(t, test.src, ""); strings.Join(got, ", ") != strings.Join(test.want, ", ") {
+			t.Errorf("%q: got %v, want %v", test.src, got, test.want)
+		}
+	}
+}
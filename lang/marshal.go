@@ -0,0 +1,374 @@
+package lang
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+var value_interface = reflect.TypeOf((*Value)(nil)).Elem()
+
+// the Value for a Go value: bools, ints, floats and strings become the same,
+// slices and arrays become tuples since vectors aren't values yet, structs become tuples with named elements
+// and a Value is kept as it is
+//
+// a struct field is named by its `lang:"name"` tag or its own name, `lang:"-"` leaves it out
+func ToValue(x any) (Value, error) {
+	if x == nil {
+		return nil, nil
+	}
+	return to_value(reflect.ValueOf(x))
+}
+
+// puts v into what target points at, the opposite of ToValue
+func FromValue(v Value, target any) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("FromValue needs a non nil pointer, not %T", target)
+	}
+	converted, err := from_value(v, rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+	rv.Elem().Set(converted)
+	return nil
+}
+
+func to_value(rv reflect.Value) (Value, error) {
+	if rv.Type().Implements(value_interface) {
+		if (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) && rv.IsNil() {
+			return nil, nil
+		}
+		return rv.Interface().(Value), nil
+	}
+	switch rv.Kind() {
+	case reflect.Bool:
+		return &BoolType{value: rv.Bool()}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &IntType{value: int(rv.Int())}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("%d does not fit in an int", rv.Uint())
+		}
+		return &IntType{value: int(rv.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return &FloatType{value: rv.Float()}, nil
+	case reflect.String:
+		return &StringType{value: rv.String()}, nil
+	case reflect.Slice, reflect.Array:
+		tuple := &TupleType{values: make([]Value, rv.Len())}
+		for i := range tuple.values {
+			element, err := to_value(rv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			tuple.values[i] = element
+		}
+		return tuple, nil
+	case reflect.Struct:
+		tuple := &TupleType{values: []Value{}}
+		for i := 0; i < rv.NumField(); i++ {
+			name, included := field_name(rv.Type().Field(i))
+			if !included {
+				continue
+			}
+			element, err := to_value(rv.Field(i))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			tuple.values = append(tuple.values, named(element, name))
+		}
+		return tuple, nil
+	case reflect.Interface, reflect.Pointer:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return to_value(rv.Elem())
+	}
+	return nil, fmt.Errorf("can not turn a %v into a value", rv.Type())
+}
+
+// what a struct field is called in a script, false if it is unexported or tagged `lang:"-"`
+func field_name(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	tag := f.Tag.Get("lang")
+	if tag == "-" {
+		return "", false
+	}
+	if tag != "" {
+		return tag, true
+	}
+	return f.Name, true
+}
+
+// v with its name set, so tuples of them can be read by name
+func named(v Value, name string) Value {
+	switch val := v.(type) {
+	case *BoolType:
+		val.name = name
+	case *IntType:
+		val.name = name
+	case *FloatType:
+		val.name = name
+	case *StringType:
+		val.name = name
+	case *TupleType:
+		val.name = name
+	}
+	return v
+}
+
+// true if every element has a name, like the tuples structs turn into
+func (tt *TupleType) named() bool {
+	for _, v := range tt.values {
+		if v == nil || v.Name() == "" {
+			return false
+		}
+	}
+	return len(tt.values) > 0
+}
+
+// the element of a tuple called name
+func (tt *TupleType) Element(name string) (Value, bool) {
+	for _, v := range tt.values {
+		if v != nil && v.Name() == name {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func from_value(v Value, t reflect.Type) (reflect.Value, error) {
+	if t.Kind() == reflect.Interface && t.NumMethod() == 0 && v != nil {
+		//any gets whatever Go type fits best
+		if native, has_native := native_type(v); has_native {
+			return from_value(v, native)
+		}
+	}
+	if t.Kind() == reflect.Interface && value_interface.Implements(t) {
+		//Value takes it as it is
+		if v == nil {
+			return reflect.Zero(t), nil
+		}
+		return reflect.ValueOf(v), nil
+	}
+	if v == nil {
+		return reflect.Value{}, fmt.Errorf("expected %v, got nothing", t)
+	}
+	rv := reflect.New(t).Elem()
+	switch val := v.(type) {
+	case *BoolType:
+		if t.Kind() == reflect.Bool {
+			rv.SetBool(val.value)
+			return rv, nil
+		}
+	case *IntType:
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if rv.OverflowInt(int64(val.value)) {
+				return rv, fmt.Errorf("%d does not fit in a %v", val.value, t)
+			}
+			rv.SetInt(int64(val.value))
+			return rv, nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if val.value < 0 || rv.OverflowUint(uint64(val.value)) {
+				return rv, fmt.Errorf("%d does not fit in a %v", val.value, t)
+			}
+			rv.SetUint(uint64(val.value))
+			return rv, nil
+		}
+	case *FloatType:
+		if t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64 {
+			rv.SetFloat(val.value)
+			return rv, nil
+		}
+	case *StringType:
+		if t.Kind() == reflect.String {
+			rv.SetString(val.value)
+			return rv, nil
+		}
+	case *TupleType:
+		if t.Kind() == reflect.Pointer {
+			//goes into what it points at, below
+			break
+		}
+		switch t.Kind() {
+		case reflect.Struct:
+			return struct_from_tuple(val, t)
+		case reflect.Map:
+			if t.Key().Kind() != reflect.String || !val.named() {
+				return rv, fmt.Errorf("expected %v, got a tuple", t)
+			}
+			rv = reflect.MakeMapWithSize(t, len(val.values))
+			for _, element := range val.values {
+				converted, err := from_value(element, t.Elem())
+				if err != nil {
+					return rv, fmt.Errorf("%s: %w", element.Name(), err)
+				}
+				rv.SetMapIndex(reflect.ValueOf(element.Name()).Convert(t.Key()), converted)
+			}
+			return rv, nil
+		case reflect.Slice:
+			rv = reflect.MakeSlice(t, len(val.values), len(val.values))
+		case reflect.Array:
+			if t.Len() != len(val.values) {
+				return rv, fmt.Errorf("expected %v, got a tuple of %d", t, len(val.values))
+			}
+		default:
+			return rv, fmt.Errorf("expected %v, got a tuple", t)
+		}
+		for i, element := range val.values {
+			converted, err := from_value(element, t.Elem())
+			if err != nil {
+				return rv, err
+			}
+			rv.Index(i).Set(converted)
+		}
+		return rv, nil
+	case *Solution:
+		switch t.Kind() {
+		case reflect.Struct:
+			for i := 0; i < t.NumField(); i++ {
+				name, included := field_name(t.Field(i))
+				if !included {
+					continue
+				}
+				if element, exists := val.values[name]; exists {
+					converted, err := from_value(element, t.Field(i).Type)
+					if err != nil {
+						return rv, fmt.Errorf("%s: %w", name, err)
+					}
+					rv.Field(i).Set(converted)
+				}
+			}
+			return rv, nil
+		case reflect.Map:
+			if t.Key().Kind() != reflect.String {
+				break
+			}
+			rv = reflect.MakeMapWithSize(t, len(val.values))
+			for name, element := range val.values {
+				converted, err := from_value(element, t.Elem())
+				if err != nil {
+					return rv, fmt.Errorf("%s: %w", name, err)
+				}
+				rv.SetMapIndex(reflect.ValueOf(name).Convert(t.Key()), converted)
+			}
+			return rv, nil
+		}
+	case *SolutionSetType:
+		//only the solutions found so far, the runtime finds the rest first
+		if t.Kind() == reflect.Slice {
+			rv = reflect.MakeSlice(t, len(val.solutions), len(val.solutions))
+			for i, sol := range val.solutions {
+				converted, err := from_value(sol, t.Elem())
+				if err != nil {
+					return rv, err
+				}
+				rv.Index(i).Set(converted)
+			}
+			return rv, nil
+		}
+	}
+	if t.Kind() == reflect.Pointer {
+		inner, err := from_value(v, t.Elem())
+		if err != nil {
+			return rv, err
+		}
+		rv.Set(reflect.New(t.Elem()))
+		rv.Elem().Set(inner)
+		return rv, nil
+	}
+	return rv, fmt.Errorf("expected %v, got %v", t, v.Type())
+}
+
+// tuple elements go to the fields with the same names, or in order if the tuple has no names
+func struct_from_tuple(tuple *TupleType, t reflect.Type) (reflect.Value, error) {
+	rv := reflect.New(t).Elem()
+	has_names := false
+	for _, element := range tuple.values {
+		has_names = has_names || (element != nil && element.Name() != "")
+	}
+	next := 0
+	for i := 0; i < t.NumField(); i++ {
+		name, included := field_name(t.Field(i))
+		if !included {
+			continue
+		}
+		var element Value
+		if has_names {
+			var found bool
+			element, found = tuple.Element(name)
+			if !found {
+				continue
+			}
+		} else {
+			if next >= len(tuple.values) {
+				return rv, fmt.Errorf("expected %v, got a tuple of %d", t, len(tuple.values))
+			}
+			element = tuple.values[next]
+			next++
+		}
+		converted, err := from_value(element, t.Field(i).Type)
+		if err != nil {
+			return rv, fmt.Errorf("%s: %w", name, err)
+		}
+		rv.Field(i).Set(converted)
+	}
+	return rv, nil
+}
+
+var any_type = reflect.TypeOf((*any)(nil)).Elem()
+
+// the Go type a value turns into when it goes into an any
+func native_type(v Value) (reflect.Type, bool) {
+	switch val := v.(type) {
+	case *BoolType:
+		return reflect.TypeOf(false), true
+	case *IntType:
+		return reflect.TypeOf(0), true
+	case *FloatType:
+		return reflect.TypeOf(0.0), true
+	case *StringType:
+		return reflect.TypeOf(""), true
+	case *TupleType:
+		if val.named() {
+			return reflect.MapOf(reflect.TypeOf(""), any_type), true
+		}
+		return reflect.SliceOf(any_type), true
+	case *SolutionSetType:
+		return reflect.SliceOf(any_type), true
+	case *Solution:
+		return reflect.MapOf(reflect.TypeOf(""), any_type), true
+	}
+	return nil, false
+}
+
+// the type scripts see a Go type as, NoType if it could be anything
+func value_type_of(t reflect.Type) (ValueType, bool) {
+	if t.Kind() == reflect.Interface && value_interface.Implements(t) {
+		return NoType, true
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return Bool, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return Int, true
+	case reflect.Float32, reflect.Float64:
+		return Float, true
+	case reflect.String:
+		return String, true
+	case reflect.Slice, reflect.Array:
+		if _, ok := value_type_of(t.Elem()); ok {
+			return Tuple, true
+		}
+	case reflect.Struct:
+		return Tuple, true
+	case reflect.Pointer:
+		return value_type_of(t.Elem())
+	}
+	return NoType, false
+}
@@ -0,0 +1,147 @@
+package lang
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type point struct {
+	X      int     `lang:"x"`
+	Y      float64 `lang:"y"`
+	Label  string
+	Hidden string `lang:"-"`
+	secret int
+}
+
+func TestToValue(t *testing.T) {
+	tests := []struct {
+		in   any
+		want string //how the script would print it
+	}{
+		{true, "true"},
+		{3, "3"},
+		{int8(-3), "-3"},
+		{uint16(7), "7"},
+		{2.5, "2.5"},
+		{float32(0.5), "0.5"},
+		{"wow", "wow"},
+		{[]int{1, 2, 3}, "<1 2 3>"},
+		{[2]string{"a", "b"}, "<a b>"},
+		{[][]int{{1}, {2, 3}}, "<<1> <2 3>>"},
+		{point{X: 1, Y: 2.5, Label: "p", Hidden: "h", secret: 4}, "<1 2.5 p>"},
+		{&point{X: 1}, "<1 0 >"},
+		{Value(&IntType{value: 4}), "4"},
+	}
+	for _, test := range tests {
+		v, err := ToValue(test.in)
+		if err != nil {
+			t.Errorf("%#v: %v", test.in, err)
+			continue
+		}
+		if got := "<" + format_values(nil, []Value{v}) + ">"; got != "<"+test.want+">" {
+			t.Errorf("%#v: got %s, want %s", test.in, got, test.want)
+		}
+	}
+	for _, bad := range []any{map[string]int{}, make(chan int), uint64(1 << 63), []any{func() {}}} {
+		if v, err := ToValue(bad); err == nil {
+			t.Errorf("%#v: got %v, want an error", bad, v)
+		}
+	}
+	if v, err := ToValue(nil); v != nil || err != nil {
+		t.Errorf("nil: got %v, %v", v, err)
+	}
+	v, _ := ToValue(point{X: 1, Y: 2.5, Label: "p"})
+	if x, found := v.(*TupleType).Element("x"); !found || x.(*IntType).value != 1 {
+		t.Errorf("x is %v", x)
+	}
+	if _, found := v.(*TupleType).Element("Hidden"); found {
+		t.Error("a field tagged - is in the tuple")
+	}
+}
+
+// anything ToValue makes, FromValue turns back into the same thing
+func TestRoundTrip(t *testing.T) {
+	values := []any{
+		true, 3, -7, 2.5, "wow", "",
+		[]int{1, 2, 3}, []string{"a"}, [3]bool{true, false, true},
+		point{X: 1, Y: 2.5, Label: "p"},
+		[]point{{X: 1}, {X: 2, Label: "two"}},
+		&point{X: 5},
+	}
+	for _, want := range values {
+		v, err := ToValue(want)
+		if err != nil {
+			t.Fatalf("%#v: %v", want, err)
+		}
+		got := reflect.New(reflect.TypeOf(want))
+		if err := FromValue(v, got.Interface()); err != nil {
+			t.Errorf("%#v: %v", want, err)
+			continue
+		}
+		if !reflect.DeepEqual(got.Elem().Interface(), want) {
+			t.Errorf("got %#v, want %#v", got.Elem().Interface(), want)
+		}
+	}
+}
+
+func TestFromValue(t *testing.T) {
+	tuple := &TupleType{values: []Value{&IntType{value: 1}, &StringType{value: "a"}}}
+	named_tuple, _ := ToValue(point{X: 3, Y: 1.5, Label: "p"})
+	tests := []struct {
+		v      Value
+		target any
+		want   any
+		err    string //what the error has in it, "" for no error
+	}{
+		{&IntType{value: 3}, new(int64), int64(3), ""},
+		{&IntType{value: 300}, new(int8), int8(0), "does not fit"},
+		{&IntType{value: -1}, new(uint), uint(0), "does not fit"},
+		{&IntType{value: 3}, new(string), "", "expected string, got int"},
+		{&FloatType{value: 1.5}, new(float32), float32(1.5), ""},
+		{&StringType{value: "s"}, new(any), "s", ""},
+		{&IntType{value: 3}, new(any), 3, ""},
+		{tuple, new(any), []any{1, "a"}, ""},
+		{named_tuple, new(any), map[string]any{"x": 3, "y": 1.5, "Label": "p"}, ""},
+		{named_tuple, new(map[string]any), map[string]any{"x": 3, "y": 1.5, "Label": "p"}, ""},
+		{tuple, new(map[string]any), map[string]any(nil), "expected map"},
+		{tuple, new([]any), []any{1, "a"}, ""},
+		{tuple, new([3]any), [3]any{}, "tuple of 2"},
+		//a tuple without names fills a struct in order
+		{tuple, new(struct {
+			N int
+			S string
+		}), struct {
+			N int
+			S string
+		}{1, "a"}, ""},
+		{tuple, new([]int), []int(nil), "expected int, got string"},
+		{&IntType{value: 2}, new(*int), ptr(2), ""},
+		{&IntType{value: 2}, new(Value), Value(&IntType{value: 2}), ""},
+		{nil, new(int), 0, "got nothing"},
+	}
+	for _, test := range tests {
+		err := FromValue(test.v, test.target)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%v into %T: got %v, want an error with %q", test.v, test.target, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v into %T: %v", test.v, test.target, err)
+			continue
+		}
+		if got := reflect.ValueOf(test.target).Elem().Interface(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v into %T: got %#v, want %#v", test.v, test.target, got, test.want)
+		}
+	}
+	var n int
+	if err := FromValue(&IntType{value: 1}, n); err == nil {
+		t.Error("FromValue took a non pointer")
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,474 @@
+package lang
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// the values an int variable in a solve block could still take, low..high without the holes
+type IntDomain struct {
+	low, high int
+	holes     map[int]bool
+}
+
+func NewIntDomain(low, high int) IntDomain {
+	return IntDomain{low: low, high: high, holes: map[int]bool{}}
+}
+
+func (d IntDomain) Empty() bool {
+	return d.low > d.high
+}
+func (d IntDomain) Fixed() bool {
+	return d.low == d.high
+}
+func (d IntDomain) Contains(v int) bool {
+	return v >= d.low && v <= d.high && !d.holes[v]
+}
+
+// how many values are left, a domain too big to count is math.MaxInt
+func (d IntDomain) Size() int {
+	if d.Empty() {
+		return 0
+	}
+	size := math.MaxInt
+	if span := d.high - d.low; span >= 0 && span < math.MaxInt {
+		size = span + 1
+	}
+	for h := range d.holes {
+		if h >= d.low && h <= d.high {
+			size--
+		}
+	}
+	return size
+}
+
+// the nth value still in the domain, counting from low
+func (d IntDomain) Nth(n int) int {
+	for v := d.low; v < d.high; v++ {
+		if d.holes[v] {
+			continue
+		}
+		if n == 0 {
+			return v
+		}
+		n--
+	}
+	return d.high
+}
+
+// pulls low and high in past any holes
+func (d *IntDomain) tighten() {
+	for d.low <= d.high && d.holes[d.low] {
+		d.low++
+	}
+	for d.low <= d.high && d.holes[d.high] {
+		d.high--
+	}
+}
+
+func (d IntDomain) copy() IntDomain {
+	holes := make(map[int]bool, len(d.holes))
+	for h := range d.holes {
+		holes[h] = true
+	}
+	return IntDomain{low: d.low, high: d.high, holes: holes}
+}
+
+func (d IntDomain) String() string {
+	if d.Empty() {
+		return "{}"
+	}
+	if d.Fixed() {
+		return fmt.Sprint(d.low)
+	}
+	s := fmt.Sprintf("%d..%d", d.low, d.high)
+	holes := []int{}
+	for h := range d.holes {
+		if h > d.low && h < d.high {
+			holes = append(holes, h)
+		}
+	}
+	sort.Ints(holes)
+	for i, h := range holes {
+		if i == 0 {
+			s += " except "
+		} else {
+			s += ", "
+		}
+		s += fmt.Sprint(h)
+	}
+	return s
+}
+
+// constant + sum of coefficients[i] * unknown i
+type Linear struct {
+	coefficients map[int]int
+	constant     int
+}
+
+func constant_linear(c int) Linear {
+	return Linear{coefficients: map[int]int{}, constant: c}
+}
+
+// a + scale*b
+func (a Linear) add(b Linear, scale int) Linear {
+	out := constant_linear(a.constant + scale*b.constant)
+	for i, c := range a.coefficients {
+		out.coefficients[i] += c
+	}
+	for i, c := range b.coefficients {
+		out.coefficients[i] += scale * c
+		if out.coefficients[i] == 0 {
+			delete(out.coefficients, i)
+		}
+	}
+	return out
+}
+
+func (a Linear) is_constant() bool {
+	return len(a.coefficients) == 0
+}
+
+// sum op 0 where op is Equality, LessEqual or NotEqual
+type LinearConstraint struct {
+	sum  Linear
+	op   TokenType
+	from *RequireNode
+}
+
+// one thing the solver learned about a variable on the way to a solution
+type PropagationStep struct {
+	unknown       int
+	variable      string
+	before, after IntDomain
+	line          int //line of the requirement that forced it, 0 if the solver picked the value
+}
+
+func (ps PropagationStep) String() string {
+	if ps.line == 0 {
+		return fmt.Sprintf("%s picked %v", ps.variable, ps.after)
+	}
+	return fmt.Sprintf("%s narrowed from %v to %v by line %d", ps.variable, ps.before, ps.after, ps.line)
+}
+
+// keeps the domains of the unknowns in a solve block consistent with the linear requirements of its body
+type Propagator struct {
+	domains     []IntDomain
+	constraints []LinearConstraint
+	watching    [][]int //constraints each unknown appears in
+
+	tracing bool
+	trace   []PropagationStep
+}
+
+func NewPropagator(domains []IntDomain, constraints []LinearConstraint) *Propagator {
+	p := &Propagator{
+		domains:     make([]IntDomain, len(domains)),
+		constraints: constraints,
+		watching:    make([][]int, len(domains)),
+	}
+	for i := range domains {
+		p.domains[i] = domains[i].copy()
+	}
+	for ci, c := range constraints {
+		for i := range c.sum.coefficients {
+			p.watching[i] = append(p.watching[i], ci)
+		}
+	}
+	return p
+}
+
+// narrows domains until nothing changes, false if some unknown has nowhere left to go
+func (p *Propagator) Propagate() bool {
+	queue := make([]int, len(p.constraints))
+	queued := make([]bool, len(p.constraints))
+	for i := range queue {
+		queue[i] = i
+		queued[i] = true
+	}
+	for len(queue) > 0 {
+		ci := queue[0]
+		queue = queue[1:]
+		queued[ci] = false
+		changed, ok := p.revise(p.constraints[ci])
+		if !ok {
+			return false
+		}
+		for _, i := range changed {
+			for _, other := range p.watching[i] {
+				if !queued[other] {
+					queued[other] = true
+					queue = append(queue, other)
+				}
+			}
+		}
+	}
+	return true
+}
+
+// fixes unknown i to v and propagates the consequences
+func (p *Propagator) Assign(i, v int) bool {
+	if p.tracing {
+		p.trace = append(p.trace, PropagationStep{unknown: i, before: p.domains[i], after: NewIntDomain(v, v)})
+	}
+	p.domains[i] = IntDomain{low: v, high: v, holes: map[int]bool{}}
+	return p.Propagate()
+}
+
+// the unassigned unknown with the fewest values left, -1 if they are all fixed
+func (p *Propagator) SmallestUnassigned() int {
+	best := -1
+	for i, d := range p.domains {
+		if d.Fixed() {
+			continue
+		}
+		if best == -1 || d.Size() < p.domains[best].Size() {
+			best = i
+		}
+	}
+	return best
+}
+
+// the smallest and largest coefficient*unknown can be
+func (p *Propagator) term_bounds(i, coefficient int) (int, int) {
+	d := p.domains[i]
+	if coefficient > 0 {
+		return coefficient * d.low, coefficient * d.high
+	}
+	return coefficient * d.high, coefficient * d.low
+}
+
+// applies one constraint, returns which unknowns it narrowed
+func (p *Propagator) revise(c LinearConstraint) ([]int, bool) {
+	if !p.tracing {
+		return p.apply(c)
+	}
+	before := map[int]IntDomain{}
+	for i := range c.sum.coefficients {
+		before[i] = p.domains[i].copy()
+	}
+	changed, ok := p.apply(c)
+	sort.Ints(changed)
+	seen := map[int]bool{}
+	for _, i := range changed {
+		if seen[i] {
+			continue
+		}
+		seen[i] = true
+		line := 0
+		if c.from != nil {
+			line = c.from.span.line
+		}
+		p.trace = append(p.trace, PropagationStep{unknown: i, before: before[i], after: p.domains[i].copy(), line: line})
+	}
+	return changed, ok
+}
+
+func (p *Propagator) apply(c LinearConstraint) ([]int, bool) {
+	switch c.op {
+	case LessEqual:
+		return p.revise_at_most(c.sum)
+	case Equality:
+		changed, ok := p.revise_at_most(c.sum)
+		if !ok {
+			return changed, false
+		}
+		negated := constant_linear(0).add(c.sum, -1)
+		more, ok := p.revise_at_most(negated)
+		return append(changed, more...), ok
+	case NotEqual:
+		return p.revise_not_equal(c.sum)
+	}
+	return nil, true
+}
+
+// sum <= 0
+func (p *Propagator) revise_at_most(sum Linear) ([]int, bool) {
+	changed := []int{}
+	min_total := sum.constant
+	for i, coefficient := range sum.coefficients {
+		low, _ := p.term_bounds(i, coefficient)
+		min_total += low
+	}
+	if min_total > 0 {
+		return changed, false
+	}
+	for i, coefficient := range sum.coefficients {
+		low, _ := p.term_bounds(i, coefficient)
+		//coefficient * x <= what is left once everything else is as small as it can be
+		limit := low - min_total
+		d := &p.domains[i]
+		before := *d
+		if coefficient > 0 {
+			if bound := floor_div(limit, coefficient); bound < d.high {
+				d.high = bound
+			}
+		} else {
+			if bound := ceil_div(limit, coefficient); bound > d.low {
+				d.low = bound
+			}
+		}
+		d.tighten()
+		if d.Empty() {
+			return changed, false
+		}
+		if d.low != before.low || d.high != before.high {
+			changed = append(changed, i)
+		}
+	}
+	return changed, true
+}
+
+// sum != 0, only does anything once all but one unknown is fixed
+func (p *Propagator) revise_not_equal(sum Linear) ([]int, bool) {
+	free := -1
+	total := sum.constant
+	for i, coefficient := range sum.coefficients {
+		if p.domains[i].Fixed() {
+			total += coefficient * p.domains[i].low
+			continue
+		}
+		if free != -1 {
+			return nil, true
+		}
+		free = i
+	}
+	if free == -1 {
+		return nil, total != 0
+	}
+	coefficient := sum.coefficients[free]
+	if total%coefficient != 0 {
+		return nil, true
+	}
+	banned := -total / coefficient
+	d := &p.domains[free]
+	if !d.Contains(banned) {
+		return nil, true
+	}
+	d.holes[banned] = true
+	d.tighten()
+	return []int{free}, !d.Empty()
+}
+
+func floor_div(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+func ceil_div(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) == (b < 0)) {
+		q++
+	}
+	return q
+}
+
+// turns the requirements of a solve block into linear constraints over its unknowns
+type constraint_collector struct {
+	r        *Runtime
+	unknowns map[string]int  //index into the propagator of each unknown int
+	assigned map[string]bool //what the body changes, what these are when a requirement runs isn't known until then
+}
+
+// requirements inside options only hold on some branches, so only the top level of the body is looked at
+func (cc *constraint_collector) collect(body *BlockNode) []LinearConstraint {
+	constraints := []LinearConstraint{}
+	for _, line := range body.lines {
+		if rn, is_require := line.(*RequireNode); is_require {
+			for _, c := range cc.condition(rn.condition) {
+				c.from = rn
+				constraints = append(constraints, c)
+			}
+		}
+	}
+	return constraints
+}
+
+// any part of the condition that is not linear is left for the body to check when it runs
+func (cc *constraint_collector) condition(n ASTNode) []LinearConstraint {
+	bon, is_binary := n.(*BinaryOpNode)
+	if !is_binary {
+		return nil
+	}
+	if bon.op == And {
+		return append(cc.condition(bon.left), cc.condition(bon.right)...)
+	}
+	l, l_ok := cc.linearize(bon.left)
+	r, r_ok := cc.linearize(bon.right)
+	if !l_ok || !r_ok {
+		return nil
+	}
+	var c LinearConstraint
+	switch bon.op {
+	case Equality:
+		c = LinearConstraint{sum: l.add(r, -1), op: Equality}
+	case NotEqual:
+		c = LinearConstraint{sum: l.add(r, -1), op: NotEqual}
+	case LessEqual:
+		c = LinearConstraint{sum: l.add(r, -1), op: LessEqual}
+	case OpenAlligator: // l < r is l - r + 1 <= 0
+		c = LinearConstraint{sum: l.add(r, -1).add(constant_linear(1), 1), op: LessEqual}
+	case GreaterEqual:
+		c = LinearConstraint{sum: r.add(l, -1), op: LessEqual}
+	case CloseAlligator:
+		c = LinearConstraint{sum: r.add(l, -1).add(constant_linear(1), 1), op: LessEqual}
+	default:
+		return nil
+	}
+	return []LinearConstraint{c}
+}
+
+func (cc *constraint_collector) linearize(n ASTNode) (Linear, bool) {
+	switch node := n.(type) {
+	case *IntLiteral:
+		return constant_linear(node.value), true
+	case *GetNode:
+		if cc.assigned[node.name] {
+			return Linear{}, false
+		}
+		if i, is_unknown := cc.unknowns[node.name]; is_unknown {
+			return Linear{coefficients: map[int]int{i: 1}}, true
+		}
+		if v, is_int := cc.r.StackTop().variables[node.name].(*IntType); is_int {
+			return constant_linear(v.value), true
+		}
+	case *IndexNode:
+		//universe[2] is whatever the third statement of the universe is
+		get, is_get := node.target.(*GetNode)
+		index, is_literal := node.index.(*IntLiteral)
+		if !is_get || !is_literal || cc.assigned[get.name] {
+			return Linear{}, false
+		}
+		if u, is_universe := cc.r.StackTop().variables[get.name].(*UniverseType); is_universe && index.value >= 0 && index.value < len(u.statements) {
+			return cc.linearize(u.statements[index.value])
+		}
+	case *NegateNode:
+		if operand, ok := cc.linearize(node.operand); ok {
+			return constant_linear(0).add(operand, -1), true
+		}
+	case *BinaryOpNode:
+		l, l_ok := cc.linearize(node.left)
+		r, r_ok := cc.linearize(node.right)
+		if !l_ok || !r_ok {
+			return Linear{}, false
+		}
+		switch node.op {
+		case Plus:
+			return l.add(r, 1), true
+		case Minus:
+			return l.add(r, -1), true
+		case Multiply:
+			//only multiplication by a constant keeps it linear
+			if l.is_constant() {
+				return constant_linear(0).add(r, l.constant), true
+			}
+			if r.is_constant() {
+				return constant_linear(0).add(l, r.constant), true
+			}
+		}
+	}
+	return Linear{}, false
+}
@@ -0,0 +1,53 @@
+package lang
+
+import (
+	"math"
+	"testing"
+)
+
+// a variable the body sets before a requirement has to be read when the requirement runs, not when the solve starts
+func TestRequireAfterAssignment(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"var k int = 0\nvar i int in 0..5\nvar u universe = (i)\nsolve u, s {\n\tk = 2\n\trequire i == k\n}\nprint s", "{i: 2}\n"},
+		{"var k int = 0\nvar i int in 0..5\nvar u universe = (i)\nsolve u, s {\n\tk = 4\n\trequire i >= k\n}\nprint s.count", "2\n"},
+		{"var i int in 0..5\nvar u universe = (i)\nsolve u, s {\n\tvar k int\n\tk = 3\n\trequire i + k == 5\n}\nprint s", "{i: 2}\n"},
+		//k isn't set in the body so its value at the solve is used
+		{"var k int = 1\nvar i int in 0..5\nvar u universe = (i)\nsolve u, s {\n\trequire i == k\n}\nk = 3\nprint s", "{i: 1}\n"},
+	}
+	for _, test := range tests {
+		if got := output_of(t, test.src); got != test.want {
+			t.Errorf("%q: printed %q, want %q", test.src, got, test.want)
+		}
+	}
+}
+
+func TestDomainSize(t *testing.T) {
+	tests := []struct {
+		d    IntDomain
+		want int
+	}{
+		{NewIntDomain(0, 9), 10},
+		{NewIntDomain(3, 2), 0},
+		{NewIntDomain(math.MinInt, math.MaxInt), math.MaxInt},
+		{NewIntDomain(0, math.MaxInt), math.MaxInt},
+		{NewIntDomain(1, math.MaxInt), math.MaxInt},
+		{NewIntDomain(-5, math.MaxInt-10), math.MaxInt - 4},
+		{IntDomain{low: 0, high: 9, holes: map[int]bool{4: true, 20: true}}, 9},
+	}
+	for _, test := range tests {
+		if got := test.d.Size(); got != test.want {
+			t.Errorf("%v: size %d, want %d", test.d, got, test.want)
+		}
+	}
+	//the whole range used to count as a size of 0 and be picked before anything smaller
+	p := &Propagator{domains: []IntDomain{NewIntDomain(math.MinInt, math.MaxInt), NewIntDomain(0, 1)}}
+	if got := p.SmallestUnassigned(); got != 1 {
+		t.Errorf("picked unknown %d, want the one with two values", got)
+	}
+	if got := NewIntDomain(math.MaxInt-1, math.MaxInt).Nth(1); got != math.MaxInt {
+		t.Errorf("second value of the last two ints is %d", got)
+	}
+}
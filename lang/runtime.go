@@ -0,0 +1,1181 @@
+package lang
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+type ValueType int
+
+const (
+	NoType ValueType = iota
+	Bool
+	Int
+	Float
+	String
+	Vector
+	Tuple
+	Function
+	Universe
+	SolutionSet
+	OneSolution
+	LastBuiltinType
+)
+
+func (v ValueType) String() string {
+	if v < LastBuiltinType {
+		return []string{"nothing", "bool", "int", "float", "string", "vector", "tuple", "function", "universe", "solution set", "solution", "LastKnownType"}[v]
+	}
+	return "User defined type"
+}
+
+type Scope struct {
+	variables map[string]Value
+	domains   map[string]IntDomain //values a variable declared with a range could take in a solve block
+	outer     *Scope               //the scope around a loop body, setting a variable it has sets it there too
+	own       map[string]bool      //the loop variable and what the loop body declared, these never go to outer
+}
+
+func (result *Scope) Merge(other *Scope) {
+	for k, v := range other.variables {
+		//locally scoped varibales take precedence over those being merged in, that is if a key exists in result and other, the value in result will be used
+		if _, in_result := result.variables[k]; !in_result {
+			result.variables[k] = v
+		}
+	}
+	for k, d := range other.domains {
+		if _, in_result := result.domains[k]; !in_result {
+			result.domains[k] = d
+		}
+	}
+}
+
+// sets a variable, a loop body also sets it in the scopes around it that have it so the loop can add things up
+func (s *Scope) set(name string, v Value) {
+	s.variables[name] = v
+	for ; s.outer != nil && !s.own[name]; s = s.outer {
+		if _, exists := s.outer.variables[name]; !exists {
+			return
+		}
+		s.outer.variables[name] = v
+	}
+}
+
+// a variable with no value yet, only in s
+func (s *Scope) declare(name string) {
+	s.variables[name] = nil
+	if s.own != nil {
+		s.own[name] = true
+	}
+}
+
+func (s *Scope) String() string {
+	out := "{\n"
+
+	keys := make([]string, 0)
+	for key := range s.variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := s.variables[k]
+		out += fmt.Sprintf("%s: %v\n", k, v)
+	}
+	return out + "}"
+}
+
+func EmptyScope() *Scope {
+	return &Scope{
+		variables: map[string]Value{},
+		domains:   map[string]IntDomain{},
+	}
+}
+
+type ASTNode interface {
+	Execute(r *Runtime)
+	ReturnsType(r *Runtime) ValueType
+	Span() Span //where in the source the node came from
+}
+
+var _ ASTNode = &SetNode{to: "", from: nil}
+var _ ASTNode = &BoolLiteral{value: false}
+var _ ASTNode = &FloatLiteral{value: 0.5}
+var _ ASTNode = &IntLiteral{value: 12}
+var _ ASTNode = &PrintStatement{argument: &IntLiteral{value: 2}}
+var _ ASTNode = &AddIntNode{}
+var _ ASTNode = &TupleLiteral{}
+var _ ASTNode = &AddAnyNode{}
+var _ ASTNode = &DeclareNode{}
+var _ ASTNode = &BlockNode{}
+var _ ASTNode = &BinaryOpNode{}
+var _ ASTNode = &NegateNode{}
+var _ ASTNode = &IndexNode{}
+var _ ASTNode = &StringLiteral{}
+var _ ASTNode = &FunctionDefinition{}
+var _ ASTNode = &CallNode{}
+var _ ASTNode = &ReturnNode{}
+
+type DeclareNode struct {
+	name    string
+	my_type ValueType
+
+	low, high ASTNode //var x int in low..high, nil if there is no range
+	span      Span
+	doc       string //from the /// comment above it
+}
+
+func (dn *DeclareNode) Execute(r *Runtime) {
+	r.StackTop().declare(dn.name)
+	if dn.low == nil {
+		return
+	}
+	dn.low.Execute(r)
+	low := r.last_expression_result
+	if r.halted() {
+		return
+	}
+	dn.high.Execute(r)
+	dn.set_range(r, low, r.last_expression_result)
+}
+
+// gives the variable the domain low..high once they have been evaluated
+func (dn *DeclareNode) set_range(r *Runtime, low_value, high_value Value) {
+	low, low_ok := low_value.(*IntType)
+	high, high_ok := high_value.(*IntType)
+	r.last_expression_result = nil
+	if !low_ok || !high_ok {
+		r.throwError(TypeMismatchError, dn.span, fmt.Sprintf("range of %s must be between two ints", dn.name))
+		return
+	}
+	r.StackTop().domains[dn.name] = NewIntDomain(low.value, high.value)
+}
+
+func (*DeclareNode) ReturnsType(r *Runtime) ValueType {
+	return NoType
+}
+
+func (dn *DeclareNode) Span() Span {
+	return dn.span
+}
+
+type SetNode struct {
+	to      string
+	my_type ValueType
+	from    ASTNode
+	span    Span
+}
+
+func (sn *SetNode) Execute(r *Runtime) {
+	sn.from.Execute(r)
+	if r.halted() {
+		//a failed expression leaves the variable as it was
+		return
+	}
+	r.StackTop().set(sn.to, r.last_expression_result)
+}
+
+func (sn *SetNode) ReturnsType(r *Runtime) ValueType {
+	return sn.from.ReturnsType(r)
+}
+
+func (sn *SetNode) Span() Span {
+	return sn.span
+}
+
+type GetNode struct {
+	name   string
+	v_type ValueType
+	span   Span
+}
+
+func (gn *GetNode) Execute(r *Runtime) {
+	v, exists := r.StackTop().variables[gn.name]
+	if !exists {
+		r.throwError(UndefinedVariableError, gn.span, fmt.Sprintf("undefined variable %s", gn.name))
+		return
+	}
+	r.last_expression_result = v
+}
+
+func (n *GetNode) ReturnsType(r *Runtime) ValueType {
+	return n.v_type
+}
+
+func (gn *GetNode) Span() Span {
+	return gn.span
+}
+
+type BoolLiteral struct {
+	value bool
+	span  Span
+}
+
+func (b *BoolLiteral) Execute(r *Runtime) {
+	r.last_expression_result = &BoolType{
+		name:  "",
+		value: b.value,
+	}
+}
+func (b *BoolLiteral) ReturnsType(r *Runtime) ValueType {
+	return Bool
+}
+
+func (b *BoolLiteral) Span() Span {
+	return b.span
+}
+
+type IntLiteral struct {
+	value int
+	span  Span
+}
+
+func (*IntLiteral) ReturnsType(r *Runtime) ValueType {
+	return Int
+}
+
+func (il *IntLiteral) Span() Span {
+	return il.span
+}
+
+func (il *IntLiteral) Execute(r *Runtime) {
+
+	r.last_expression_result = &IntType{
+		name:  "",
+		value: il.value,
+	}
+}
+
+type FloatLiteral struct {
+	value float64
+	span  Span
+}
+
+func (*FloatLiteral) ReturnsType(r *Runtime) ValueType {
+	return Float
+}
+
+func (fl *FloatLiteral) Span() Span {
+	return fl.span
+}
+
+func (fl *FloatLiteral) Execute(r *Runtime) {
+	r.last_expression_result = &FloatType{
+		name:  "",
+		value: fl.value,
+	}
+}
+
+type StringLiteral struct {
+	value string
+	span  Span
+}
+
+func (*StringLiteral) ReturnsType(r *Runtime) ValueType {
+	return String
+}
+
+func (sl *StringLiteral) Span() Span {
+	return sl.span
+}
+
+func (sl *StringLiteral) Execute(r *Runtime) {
+	r.last_expression_result = &StringType{
+		name:  "",
+		value: sl.value,
+	}
+}
+
+// a list of statements run one after another in the current scope
+type BlockNode struct {
+	lines []ASTNode
+	span  Span
+}
+
+func (bn *BlockNode) Execute(r *Runtime) {
+	for _, line := range bn.lines {
+		if !r.step(line.Span()) {
+			return
+		}
+		line.Execute(r)
+		if r.halted() {
+			return
+		}
+	}
+}
+
+func (*BlockNode) ReturnsType(r *Runtime) ValueType {
+	return NoType
+}
+
+func (bn *BlockNode) Span() Span {
+	return bn.span
+}
+
+type OperatorKey struct {
+	op          TokenType
+	left, right ValueType
+}
+
+// operators that exist without anyone overloading them
+var builtin_operators = map[OperatorKey]BinaryOperation{
+	{Plus, Int, Int}:     int_operation(func(a, b int) int { return a + b }),
+	{Minus, Int, Int}:    int_operation(func(a, b int) int { return a - b }),
+	{Multiply, Int, Int}: int_operation(func(a, b int) int { return a * b }),
+	{Equality, Int, Int}: {Int, Int, Bool, func(a, b Value) Value {
+		return &BoolType{value: a.(*IntType).value == b.(*IntType).value}
+	}},
+	{NotEqual, Int, Int}:           int_comparison(func(a, b int) bool { return a != b }),
+	{OpenAlligator, Int, Int}:      int_comparison(func(a, b int) bool { return a < b }),
+	{LessEqual, Int, Int}:          int_comparison(func(a, b int) bool { return a <= b }),
+	{CloseAlligator, Int, Int}:     int_comparison(func(a, b int) bool { return a > b }),
+	{GreaterEqual, Int, Int}:       int_comparison(func(a, b int) bool { return a >= b }),
+	{Plus, Float, Float}:           float_operation(func(a, b float64) float64 { return a + b }),
+	{Minus, Float, Float}:          float_operation(func(a, b float64) float64 { return a - b }),
+	{Multiply, Float, Float}:       float_operation(func(a, b float64) float64 { return a * b }),
+	{Divide, Float, Float}:         float_operation(func(a, b float64) float64 { return a / b }),
+	{Equality, Float, Float}:       float_comparison(func(a, b float64) bool { return a == b }),
+	{NotEqual, Float, Float}:       float_comparison(func(a, b float64) bool { return a != b }),
+	{OpenAlligator, Float, Float}:  float_comparison(func(a, b float64) bool { return a < b }),
+	{LessEqual, Float, Float}:      float_comparison(func(a, b float64) bool { return a <= b }),
+	{CloseAlligator, Float, Float}: float_comparison(func(a, b float64) bool { return a > b }),
+	{GreaterEqual, Float, Float}:   float_comparison(func(a, b float64) bool { return a >= b }),
+	{NotEqual, Bool, Bool}:         bool_operation(func(a, b bool) bool { return a != b }),
+	{Equality, Bool, Bool}:         bool_operation(func(a, b bool) bool { return a == b }),
+	{And, Bool, Bool}:              bool_operation(func(a, b bool) bool { return a && b }),
+	{Or, Bool, Bool}:               bool_operation(func(a, b bool) bool { return a || b }),
+	//!a is true ! a, the same way -a is 0 - a
+	{Not, Bool, Bool}: bool_operation(func(_, b bool) bool { return !b }),
+	{Plus, String, String}: {String, String, String, func(a, b Value) Value {
+		return &StringType{value: a.(*StringType).value + b.(*StringType).value}
+	}},
+	{Equality, String, String}: {String, String, Bool, func(a, b Value) Value {
+		return &BoolType{value: a.(*StringType).value == b.(*StringType).value}
+	}},
+}
+
+func int_operation(f func(a, b int) int) BinaryOperation {
+	return BinaryOperation{Int, Int, Int, func(a, b Value) Value {
+		return &IntType{value: f(a.(*IntType).value, b.(*IntType).value)}
+	}}
+}
+func int_comparison(f func(a, b int) bool) BinaryOperation {
+	return BinaryOperation{Int, Int, Bool, func(a, b Value) Value {
+		return &BoolType{value: f(a.(*IntType).value, b.(*IntType).value)}
+	}}
+}
+func float_operation(f func(a, b float64) float64) BinaryOperation {
+	return BinaryOperation{Float, Float, Float, func(a, b Value) Value {
+		return &FloatType{value: f(a.(*FloatType).value, b.(*FloatType).value)}
+	}}
+}
+func float_comparison(f func(a, b float64) bool) BinaryOperation {
+	return BinaryOperation{Float, Float, Bool, func(a, b Value) Value {
+		return &BoolType{value: f(a.(*FloatType).value, b.(*FloatType).value)}
+	}}
+}
+func bool_operation(f func(a, b bool) bool) BinaryOperation {
+	return BinaryOperation{Bool, Bool, Bool, func(a, b Value) Value {
+		return &BoolType{value: f(a.(*BoolType).value, b.(*BoolType).value)}
+	}}
+}
+
+// any binary operator, looked up by the types of what it is given when it is run
+type BinaryOpNode struct {
+	op          TokenType
+	left, right ASTNode
+	span        Span
+	op_span     Span //just the operator, for pointing at in errors
+}
+
+func (bon *BinaryOpNode) Execute(r *Runtime) {
+	bon.left.Execute(r)
+	lval := r.last_expression_result
+	if r.halted() {
+		return
+	}
+	bon.right.Execute(r)
+	rval := r.last_expression_result
+	if r.halted() {
+		return
+	}
+	bon.apply(r, lval, rval)
+}
+
+// the operator on values that have already been evaluated
+func (bon *BinaryOpNode) apply(r *Runtime, lval, rval Value) {
+	if lval == nil || rval == nil {
+		r.throwError(NoValueError, bon.op_span, fmt.Sprintf("can not use %s on a variable with no value", bon.op.Lexeme()))
+		return
+	}
+	if (bon.op == Divide || bon.op == Modulo) && lval.Type() == Int && rval.Type() == Int {
+		if rval.(*IntType).value == 0 {
+			r.throwError(DivisionByZeroError, bon.op_span, "integer division by zero")
+			return
+		}
+		if bon.op == Modulo {
+			r.last_expression_result = &IntType{value: lval.(*IntType).value % rval.(*IntType).value}
+		} else {
+			r.last_expression_result = &IntType{value: lval.(*IntType).value / rval.(*IntType).value}
+		}
+		return
+	}
+	operation, operation_exists := builtin_operators[OperatorKey{bon.op, lval.Type(), rval.Type()}]
+	if !operation_exists && bon.op == Not {
+		r.throwError(MissingOverloadError, bon.op_span, fmt.Sprintf("can not use ! on %v", rval.Type()))
+		return
+	}
+	if !operation_exists {
+		r.throwError(MissingOverloadError, bon.op_span, fmt.Sprintf("No operator %s exists between %v and %v", bon.op.Lexeme(), lval.Type(), rval.Type()))
+		return
+	}
+	r.last_expression_result = operation.operation(lval, rval)
+	r.check_length(r.last_expression_result, bon.span)
+}
+
+func (bon *BinaryOpNode) ReturnsType(r *Runtime) ValueType {
+	l, rt := bon.left.ReturnsType(r), bon.right.ReturnsType(r)
+	if (bon.op == Divide || bon.op == Modulo) && l == Int && rt == Int {
+		return Int
+	}
+	if operation, exists := builtin_operators[OperatorKey{bon.op, l, rt}]; exists {
+		return operation.ret_type
+	}
+	return NoType
+}
+
+func (bon *BinaryOpNode) Span() Span {
+	return bon.span
+}
+
+// -operand, for ints and floats
+type NegateNode struct {
+	operand ASTNode
+	span    Span
+	op_span Span
+}
+
+func (nn *NegateNode) Execute(r *Runtime) {
+	nn.operand.Execute(r)
+	if r.halted() {
+		return
+	}
+	nn.negate(r, r.last_expression_result)
+}
+
+func (nn *NegateNode) negate(r *Runtime, v Value) {
+	switch value := v.(type) {
+	case nil:
+		r.throwError(NoValueError, nn.op_span, "can not use - on a variable with no value")
+	case *IntType:
+		r.last_expression_result = &IntType{value: -value.value}
+	case *FloatType:
+		r.last_expression_result = &FloatType{value: -value.value}
+	default:
+		r.throwError(MissingOverloadError, nn.op_span, fmt.Sprintf("can not use - on %v", v.Type()))
+	}
+}
+
+func (nn *NegateNode) ReturnsType(r *Runtime) ValueType {
+	if t := nn.operand.ReturnsType(r); t == Int || t == Float {
+		return t
+	}
+	return NoType
+}
+
+func (nn *NegateNode) Span() Span {
+	return nn.span
+}
+
+// target[index] of a tuple or universe
+type IndexNode struct {
+	target, index ASTNode
+	span          Span
+}
+
+func (in *IndexNode) Execute(r *Runtime) {
+	in.index.Execute(r)
+	if r.halted() {
+		return
+	}
+	i, is_int := r.last_expression_result.(*IntType)
+	if !is_int {
+		r.throwError(TypeMismatchError, in.span, "index must be an int")
+		return
+	}
+	in.target.Execute(r)
+	if r.halted() {
+		return
+	}
+	in.index_into(r, r.last_expression_result, i)
+}
+
+func (in *IndexNode) index_into(r *Runtime, target_value Value, i *IntType) {
+	switch target := target_value.(type) {
+	case *TupleType:
+		if i.value < 0 || i.value >= len(target.values) {
+			r.throwError(IndexOutOfRangeError, in.span, fmt.Sprintf("index %d out of range of tuple of length %d", i.value, len(target.values)))
+			return
+		}
+		r.last_expression_result = target.values[i.value]
+	case *SolutionSetType:
+		if i.value >= 0 {
+			if sol, found := target.At(r, i.value); found {
+				r.last_expression_result = sol
+				return
+			}
+		}
+		r.throwError(IndexOutOfRangeError, in.span, fmt.Sprintf("there is no solution %d", i.value))
+	case *UniverseType:
+		if i.value < 0 || i.value >= len(target.statements) {
+			r.throwError(IndexOutOfRangeError, in.span, fmt.Sprintf("index %d out of range of universe of length %d", i.value, len(target.statements)))
+			return
+		}
+		//universes hold their statements unevaluated, asking for one evaluates it with whatever the variables are right now
+		target.statements[i.value].Execute(r)
+	default:
+		r.throwError(MissingOverloadError, in.span, fmt.Sprintf("can not index into %v", target_value))
+	}
+}
+
+// not known until the target is looked at when running
+func (in *IndexNode) ReturnsType(r *Runtime) ValueType {
+	return NoType
+}
+
+func (in *IndexNode) Span() Span {
+	return in.span
+}
+
+type AddAnyNode struct {
+	left, right ASTNode
+	span        Span
+}
+
+// Execute implements ASTNode
+func (aan *AddAnyNode) Execute(r *Runtime) {
+	operation, operation_exists := r.unary_operator_overloads[[2]ValueType{aan.left.ReturnsType(r), aan.right.ReturnsType(r)}]
+	if !operation_exists {
+		r.throwError(MissingOverloadError, aan.span, fmt.Sprintf("No overloaded operator exists between %v and %v", aan.left.ReturnsType(r), aan.right.ReturnsType(r)))
+		return
+	}
+	aan.left.Execute(r)
+	lval := r.last_expression_result
+	aan.right.Execute(r)
+	rval := r.last_expression_result
+	r.last_expression_result = operation.operation(lval, rval)
+
+}
+
+// ReturnsType implements ASTNode
+func (aan *AddAnyNode) ReturnsType(r *Runtime) ValueType {
+	operation, operation_exists := r.unary_operator_overloads[[2]ValueType{aan.left.ReturnsType(r), aan.right.ReturnsType(r)}]
+	if !operation_exists {
+		// no corresponding operation defined
+		return NoType
+	}
+	return operation.ret_type
+}
+
+func (aan *AddAnyNode) Span() Span {
+	return aan.span
+}
+
+type AddIntNode struct {
+	left, right ASTNode
+	span        Span
+}
+
+func (ain *AddIntNode) Execute(r *Runtime) {
+	ain.left.Execute(r)
+	ln := r.last_expression_result
+	ain.right.Execute(r)
+	rn := r.last_expression_result
+
+	l_int := 0
+	r_int := 0
+	switch li := ln.(type) {
+	case *IntType:
+		l_int = li.value
+	}
+	switch ri := rn.(type) {
+	case *IntType:
+		r_int = ri.value
+	}
+	sum := l_int + r_int
+	r.last_expression_result = &IntType{
+		name:  "",
+		value: sum,
+	}
+}
+func (ain *AddIntNode) ReturnsType(r *Runtime) ValueType {
+	return Int
+}
+
+func (ain *AddIntNode) Span() Span {
+	return ain.span
+}
+
+type SubIntNode struct {
+	left, right ASTNode
+	span        Span
+}
+
+func (sin *SubIntNode) Execute(r *Runtime) {
+	sin.left.Execute(r)
+	ln := r.last_expression_result
+	sin.right.Execute(r)
+	rn := r.last_expression_result
+
+	l_int := 0
+	r_int := 0
+	switch li := ln.(type) {
+	case *IntType:
+		l_int = li.value
+	}
+	switch ri := rn.(type) {
+	case *IntType:
+		r_int = ri.value
+	}
+	sum := l_int + r_int
+	r.last_expression_result = &IntType{
+		name:  "",
+		value: sum,
+	}
+}
+func (sin *SubIntNode) ReturnsType(r *Runtime) ValueType {
+	return Int
+}
+
+func (sin *SubIntNode) Span() Span {
+	return sin.span
+}
+
+type TupleLiteral struct {
+	values []ASTNode
+	span   Span
+}
+
+func (tl *TupleLiteral) Execute(r *Runtime) {
+	values := make([]Value, len(tl.values))
+	for i := range tl.values {
+		tl.values[i].Execute(r)
+		if r.halted() {
+			return
+		}
+		values[i] = r.last_expression_result
+	}
+	tl.make(r, values)
+}
+
+func (tl *TupleLiteral) make(r *Runtime, values []Value) {
+	r.last_expression_result = &TupleType{
+		name:   "",
+		values: values,
+	}
+	r.check_length(r.last_expression_result, tl.span)
+}
+
+func (*TupleLiteral) ReturnsType(r *Runtime) ValueType {
+	return Tuple
+}
+
+func (tl *TupleLiteral) Span() Span {
+	return tl.span
+}
+
+type PrintStatement struct {
+	argument ASTNode
+	span     Span
+}
+
+func (ps *PrintStatement) Execute(r *Runtime) {
+	ps.argument.Execute(r)
+	if r.halted() {
+		return
+	}
+	ps.print(r, r.last_expression_result)
+}
+
+func (ps *PrintStatement) print(r *Runtime, arg Value) {
+	if tuple, is_tuple := arg.(*TupleType); is_tuple {
+		//the outer brackets are left off, print <a b> says a and b
+		fmt.Fprintln(r.output, format_values(r, tuple.values))
+		return
+	}
+	fmt.Fprintln(r.output, format_value(r, arg))
+}
+
+// how print shows a value, solution sets are searched all the way through first if there is a runtime to do it
+func format_value(r *Runtime, v Value) string {
+	switch val := v.(type) {
+	case nil:
+		return "nothing"
+	case *TupleType:
+		return "<" + format_values(r, val.values) + ">"
+	case *SolutionSetType:
+		if r != nil {
+			val.Materialize(r)
+		}
+	}
+	return v.String()
+}
+
+func format_values(r *Runtime, values []Value) string {
+	s := ""
+	for i, v := range values {
+		if i > 0 {
+			s += " "
+		}
+		s += format_value(r, v)
+	}
+	return s
+}
+
+func (*PrintStatement) ReturnsType(r *Runtime) ValueType {
+	return NoType
+}
+
+func (ps *PrintStatement) Span() Span {
+	return ps.span
+}
+
+type FunctionDefinition struct {
+	name           string
+	parameterNames []string
+	parameterTypes []ValueType
+	returnType     ValueType
+
+	lines []ASTNode
+	span  Span
+	doc   string //from the /// comment above it
+}
+
+// functions are looked up through Runtime.named_places, so there is nothing to do when the definition is reached
+func (fd *FunctionDefinition) Execute(r *Runtime) {
+}
+
+func (*FunctionDefinition) ReturnsType(r *Runtime) ValueType {
+	return NoType
+}
+
+func (fd *FunctionDefinition) Span() Span {
+	return fd.span
+}
+
+// runs the function in its own scope, the result is left in last_expression_result
+func (fd *FunctionDefinition) Call(r *Runtime, args []Value, from Span) {
+	r.call_stack = append(r.call_stack, Frame{function: fd.name, called_from: from})
+	r.NewIsolatedScope()
+	for i, name := range fd.parameterNames {
+		r.StackTop().variables[name] = args[i]
+	}
+	r.last_expression_result = nil
+	for _, line := range fd.lines {
+		if !r.step(line.Span()) {
+			break
+		}
+		line.Execute(r)
+		if r.halted() {
+			break
+		}
+	}
+	var result Value
+	if r.returning {
+		result = r.return_value
+		r.returning = false
+		r.return_value = nil
+	}
+	r.PopScope()
+	r.call_stack = r.call_stack[:len(r.call_stack)-1]
+	r.last_expression_result = result
+}
+
+// name(args)
+type CallNode struct {
+	name     string
+	args     []ASTNode
+	ret_type ValueType //filled in by the parser once it knows, functions can be called before they are defined
+	span     Span
+}
+
+func (cn *CallNode) Execute(r *Runtime) {
+	place, exists := r.named_places[cn.name]
+	if !exists {
+		if hf := r.host.lookup(cn.name); hf != nil {
+			cn.call_host(r, hf)
+			return
+		}
+		r.throwError(UndefinedFunctionError, cn.span, fmt.Sprintf("undefined function %s", cn.name))
+		return
+	}
+	fd := r.ASTLines[place].(*FunctionDefinition)
+	if len(cn.args) != len(fd.parameterNames) {
+		r.throwError(ArgumentCountError, cn.span, fmt.Sprintf("%s takes %d arguments but was given %d", cn.name, len(fd.parameterNames), len(cn.args)))
+		return
+	}
+	args := make([]Value, len(cn.args))
+	for i := range cn.args {
+		cn.args[i].Execute(r)
+		if r.halted() {
+			return
+		}
+		args[i] = r.last_expression_result
+	}
+	if !r.can_call(cn.name, cn.span) {
+		return
+	}
+	fd.Call(r, args, cn.span)
+}
+
+func (cn *CallNode) call_host(r *Runtime, hf *HostFunction) {
+	if hf.params != nil && len(cn.args) != len(hf.params) {
+		r.throwError(ArgumentCountError, cn.span, fmt.Sprintf("%s takes %d arguments but was given %d", cn.name, len(hf.params), len(cn.args)))
+		return
+	}
+	args := make([]Value, len(cn.args))
+	for i := range cn.args {
+		cn.args[i].Execute(r)
+		if r.halted() {
+			return
+		}
+		args[i] = r.last_expression_result
+	}
+	cn.call_host_with(r, hf, args)
+}
+
+func (cn *CallNode) call_host_with(r *Runtime, hf *HostFunction, args []Value) {
+	result, err := hf.call_recovering(args)
+	if ae, is_argument := err.(argument_error); is_argument {
+		r.throwError(TypeMismatchError, cn.args[ae.index].Span(), fmt.Sprintf("%s %v", cn.name, ae))
+		return
+	}
+	if err != nil {
+		r.throwError(HostFunctionError, cn.span, fmt.Sprintf("%s: %v", cn.name, err))
+		return
+	}
+	r.last_expression_result = result
+	r.check_length(result, cn.span)
+}
+
+func (cn *CallNode) ReturnsType(r *Runtime) ValueType {
+	return cn.ret_type
+}
+
+func (cn *CallNode) Span() Span {
+	return cn.span
+}
+
+type ReturnNode struct {
+	value ASTNode //nil for a bare return
+	span  Span
+}
+
+func (rn *ReturnNode) Execute(r *Runtime) {
+	r.return_value = nil
+	if rn.value != nil {
+		rn.value.Execute(r)
+		if r.halted() {
+			return
+		}
+		r.return_value = r.last_expression_result
+	}
+	r.returning = true
+}
+
+func (*ReturnNode) ReturnsType(r *Runtime) ValueType {
+	return NoType
+}
+
+func (rn *ReturnNode) Span() Span {
+	return rn.span
+}
+
+type BinaryOperation struct {
+	a_type, b_type ValueType
+	ret_type       ValueType
+	operation      func(a, b Value) Value
+}
+type Runtime struct {
+	unary_operator_overloads map[[2]ValueType]BinaryOperation
+	global_scope             *Scope
+	scope_stack              []*Scope
+	stack_depth              int
+	last_expression_result   Value
+	last_error               error
+	solver                   *Solver //non nil while running inside a solve block
+	diagnostics              ErrorCollector
+	host                     *Host     //go functions the program can call, nil if there are none
+	output                   io.Writer //where print goes
+	trace                    io.Writer //what the runtime is doing, for debugging it, nil if no one is listening
+	ctx                      context.Context
+	limits                   Limits
+	steps                    int
+	call_stack               []Frame
+	returning                bool //a return statement has been hit and the rest of the function should be skipped
+	return_value             Value
+
+	ASTLines []ASTNode //outer level is []functions
+
+	named_places map[string]int //index of function() in ASTLines
+
+	current_line int
+}
+
+func (r *Runtime) StackTop() *Scope {
+	return r.scope_stack[len(r.scope_stack)-1]
+}
+
+/*
+Creates a scope that has access to global variables, local variables outside it, and has space for new variables
+var a int = 3
+
+	for (var i int = 0; i<a; i++){
+		//stuff in here has access to outer scope (a), global variables, and the new variables (i)
+	}
+*/
+func (r *Runtime) NewLocalScope() {
+	es := EmptyScope()
+	last_stack := r.scope_stack[len(r.scope_stack)-1]
+	es.Merge(last_stack) //last stack has global variables in it already
+
+	r.scope_stack = append(r.scope_stack, es)
+}
+
+// the scope a loop body runs in, made once for the whole loop, variable is the loop variable
+func (r *Runtime) NewLoopScope(variable string) {
+	r.NewLocalScope()
+	top := r.StackTop()
+	top.outer = r.scope_stack[len(r.scope_stack)-2]
+	top.own = map[string]bool{variable: true}
+}
+
+// starts the next time round the loop with v as the loop variable, what the body declared
+// last time round is gone again so it is as if the scope was made new
+// everything else is already the same as outer since setting it set it there too
+func (s *Scope) next_iteration(variable string, v Value) {
+	for name := range s.own {
+		if name == variable {
+			continue
+		}
+		if outer_v, exists := s.outer.variables[name]; exists {
+			s.variables[name] = outer_v
+		} else {
+			delete(s.variables, name)
+		}
+		if d, exists := s.outer.domains[name]; exists {
+			s.domains[name] = d
+		} else {
+			delete(s.domains, name)
+		}
+		delete(s.own, name)
+	}
+	s.own[variable] = true
+	s.variables[variable] = v
+}
+
+/*
+Creates a scope that is isolated from everthing but global variables
+
+	func stuff(args){
+		in here only has args, global variables and anything else defined in here
+	}
+*/
+func (r *Runtime) NewIsolatedScope() {
+	es := EmptyScope()
+	es.Merge(r.global_scope)
+	r.scope_stack = append(r.scope_stack, es)
+}
+func (r *Runtime) PopScope() {
+	r.scope_stack = r.scope_stack[:len(r.scope_stack)-1]
+}
+
+// sets a global variable, for giving a program its input before it runs
+func (r *Runtime) SetGlobal(name string, v Value) {
+	r.global_scope.variables[name] = v
+}
+
+// a global variable, false if there is no such variable or it has no value
+func (r *Runtime) Global(name string) (Value, bool) {
+	v, exists := r.global_scope.variables[name]
+	return v, exists && v != nil
+}
+
+// what the last expression that ran evaluated to, nil if nothing has
+func (r *Runtime) LastResult() Value {
+	return r.last_expression_result
+}
+
+type RuntimeErrorKind int
+
+const (
+	TypeMismatchError RuntimeErrorKind = iota
+	MissingOverloadError
+	IndexOutOfRangeError
+	DivisionByZeroError
+	UndefinedVariableError
+	UndefinedFunctionError
+	RequirementError
+	NoValueError
+	ArgumentCountError
+	UnboundedVariableError
+	HostFunctionError
+	CancelledError
+	StepLimitError
+	CallDepthError
+	LengthLimitError
+)
+
+// the stable code each kind is reported with, the parser uses the same ones for the same mistakes
+func (k RuntimeErrorKind) Code() string {
+	return [...]string{
+		"E0205",
+		"E0303",
+		"E0302",
+		"E0301",
+		"E0201",
+		"E0202",
+		"E0305",
+		"E0304",
+		"E0206",
+		"E0307",
+		"E0308",
+		"E0309",
+		"E0310",
+		"E0311",
+		"E0312",
+	}[k]
+}
+
+// a function call that was running when an error happened
+type Frame struct {
+	function    string
+	called_from Span
+}
+
+// an error that stops the program, located at the node that caused it
+type RuntimeError struct {
+	LocatedError
+	kind  RuntimeErrorKind
+	stack []Frame //innermost call last
+	cause error   //what outside the program caused it, like the context being cancelled
+}
+
+func (re RuntimeError) Unwrap() error {
+	return re.cause
+}
+
+func (re RuntimeError) Error() string {
+	s := re.LocatedError.Error()
+	for _, call := range re.calls() {
+		s += "\n    " + call
+	}
+	return s
+}
+
+// the stack innermost first, the same call many times in a row is only said once so deep recursion stays readable
+func (re RuntimeError) calls() []string {
+	calls := []string{}
+	for i := len(re.stack) - 1; i >= 0; {
+		f := re.stack[i]
+		same := 1
+		for i-same >= 0 && re.stack[i-same] == f {
+			same++
+		}
+		call := fmt.Sprintf("in %s called from line %d:%d", f.function, f.called_from.line, f.called_from.column_start+1)
+		if same > 1 {
+			call += fmt.Sprintf(" (%d times)", same)
+		}
+		calls = append(calls, call)
+		i -= same
+	}
+	return calls
+}
+
+// stops the program, only the first error is kept since anything after it is probably caused by it
+func (r *Runtime) throwError(kind RuntimeErrorKind, span Span, s string) {
+	r.throwErrorCause(kind, span, s, nil)
+}
+func (r *Runtime) throwErrorCause(kind RuntimeErrorKind, span Span, s string, cause error) {
+	if r.last_error != nil {
+		return
+	}
+	r.last_error = RuntimeError{
+		LocatedError: span.Located(kind.Code(), s),
+		kind:         kind,
+		stack:        append([]Frame{}, r.call_stack...),
+		cause:        cause,
+	}
+	r.last_expression_result = nil
+}
+
+// where print goes, os.Stdout unless this is called
+func (r *Runtime) SetOutput(w io.Writer) {
+	r.output = w
+}
+
+// says each top level statement as it runs, nil turns it off
+func (r *Runtime) SetTrace(w io.Writer) {
+	r.trace = w
+}
+
+func (r *Runtime) tracef(format string, args ...any) {
+	if r.trace != nil {
+		fmt.Fprintf(r.trace, format, args...)
+	}
+}
+
+// true when the rest of the current block should not run, ie. a requirement in a solve branch failed or there was an error
+func (r *Runtime) halted() bool {
+	return r.last_error != nil || r.returning || (r.solver != nil && r.solver.failed)
+}
+func (r *Runtime) Run() error {
+	return r.RunContext(context.Background())
+}
+
+// Run, but stops once ctx is done, the error it stops with wraps ctx.Err()
+func (r *Runtime) RunContext(ctx context.Context) error {
+	r.ctx = ctx
+	defer func() { r.ctx = no_context }()
+	for r.current_line < len(r.ASTLines) {
+		line := r.ASTLines[r.current_line]
+		if !r.step(line.Span()) {
+			return r.last_error
+		}
+		r.tracef("line %d: %+v\n", r.current_line, line)
+
+		line.Execute(r)
+		if r.last_error != nil {
+			return r.last_error
+		}
+
+		r.current_line++
+	}
+	return nil
+}
+
+func NewRuntime(program []ASTNode) *Runtime {
+	named_places := map[string]int{}
+	for i, line := range program {
+		if fd, is_function := line.(*FunctionDefinition); is_function {
+			named_places[fd.name] = i
+		}
+	}
+	//the top level scope is the global one, so functions see what is declared there
+	global_scope := EmptyScope()
+	return &Runtime{
+		unary_operator_overloads: map[[2]ValueType]BinaryOperation{},
+		global_scope:             global_scope,
+		scope_stack:              []*Scope{global_scope},
+		stack_depth:              0,
+		last_expression_result:   nil,
+		last_error:               nil,
+		ASTLines:                 program,
+		named_places:             named_places,
+		current_line:             0,
+		ctx:                      no_context,
+		output:                   os.Stdout,
+	}
+}
@@ -0,0 +1,94 @@
+package lang
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNegate(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"var i int = 3\nprint -i", "-3\n"},
+		{"var f float = 2.5\nprint -f", "-2.5\n"},
+		{"var f float = 2.5\nprint -f + 1.0", "-1.5\n"},
+		{"var f float = -2.5\nprint -(-f)", "-2.5\n"},
+		{"var i int = 3\nprint 2 - -i", "5\n"},
+		{"print -3", "-3\n"},
+		{"print -1.5", "-1.5\n"},
+		{"var x int in 0..5\nvar u universe = (x)\nsolve u, s {\n\trequire -x == -2\n}\nprint s", "{x: 2}\n"},
+	}
+	for _, test := range tests {
+		if got := output_of(t, test.src); got != test.want {
+			t.Errorf("%q: printed %q, want %q", test.src, got, test.want)
+		}
+	}
+	for _, vm := range []bool{false, true} {
+		_, err := run_source(t, "var s string = \"a\"\nprint -s", vm)
+		if err == nil || !strings.Contains(err.Error(), "can not use - on string") {
+			t.Errorf("negating a string with vm %v: %v", vm, err)
+		}
+	}
+}
+
+// columns count characters, ñ is two bytes but one column
+func TestCallStackColumns(t *testing.T) {
+	src := "func f(n int) int {\n\treturn 1 / n\n}\nvar ñame int = f(0)\nprint ñame"
+	for _, vm := range []bool{false, true} {
+		_, err := run_source(t, src, vm)
+		if err == nil || !strings.Contains(err.Error(), "in f called from line 4:16") {
+			t.Errorf("vm %v: %v", vm, err)
+		}
+	}
+	prog, _ := Compile(src)
+	for _, line := range prog.ast {
+		if set, is_set := line.(*SetNode); is_set {
+			if got := set.Span().String(); got != "<main>:4:1-19" {
+				t.Errorf("span of the line with ñ is %s", got)
+			}
+		}
+	}
+}
+
+// runtime errors are values with a code, where they happened and the calls that led there, the same from either engine
+func TestRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		src    string
+		code   string
+		line   int
+		column int
+		notes  []string
+	}{
+		{"print 1 / 0", "E0301", 1, 9, nil},
+		{"var t tuple = (1, 2)\nprint t[5]", "E0302", 2, 7, nil},
+		{"print \"a\" - 1", "E0303", 1, 11, nil},
+		{"var n int\nprint n + 1", "E0304", 2, 9, nil},
+		{"var x int\nvar u universe = (x)\nsolve u, s {\n}\nprint s", "E0307", 3, 1, nil},
+		{"var x int in 0..3\nvar u universe = (x)\nsolve u, s limit -1 {\n}\nprint s", "E0205", 3, 1, nil},
+		{"func f(n int) int {\n\treturn g(n)\n}\nfunc g(n int) int {\n\treturn n / 0\n}\nprint f(1)", "E0301", 5, 11,
+			[]string{"in g called from line 2:9", "in f called from line 7:7"}},
+		{"func f(n int) int {\n\treturn f(n + 1)\n}\nprint f(1)", "E0311", 2, 9,
+			[]string{"in f called from line 2:9 (9999 times)", "in f called from line 4:7"}},
+	}
+	for _, test := range tests {
+		prog, diags := Compile(test.src)
+		if prog == nil {
+			t.Fatalf("%q: %v", test.src, diags)
+		}
+		for _, vm := range []bool{false, true} {
+			res, err := prog.Run(context.Background(), RunOptions{VM: vm})
+			var re RuntimeError
+			if !errors.As(err, &re) {
+				t.Errorf("%q, vm %v: got %v", test.src, vm, err)
+				continue
+			}
+			d := res.Diagnostics[len(res.Diagnostics)-1]
+			if d.Code != test.code || d.Line != test.line || d.Column != test.column || strings.Join(d.Notes, ", ") != strings.Join(test.notes, ", ") {
+				t.Errorf("%q, vm %v: got %s at %d:%d with %v, want %s at %d:%d with %v", test.src, vm, d.Code, d.Line, d.Column, d.Notes, test.code, test.line, test.column, test.notes)
+			}
+		}
+	}
+}
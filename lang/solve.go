@@ -0,0 +1,674 @@
+package lang
+
+import (
+	"fmt"
+	"sort"
+)
+
+var _ ASTNode = &UniverseLiteral{}
+var _ ASTNode = &SolveNode{}
+var _ ASTNode = &OptionNode{}
+var _ ASTNode = &RequireNode{}
+var _ ASTNode = &ForInNode{}
+var _ ASTNode = &PropertyNode{}
+var _ Value = &Solution{}
+
+// (a, b, a && b) when being assigned to a universe, parsed but not evaluated
+type UniverseLiteral struct {
+	statements []ASTNode
+	span       Span
+}
+
+func (ul *UniverseLiteral) Execute(r *Runtime) {
+	r.last_expression_result = &UniverseType{
+		name:       "",
+		statements: ul.statements,
+	}
+}
+
+func (*UniverseLiteral) ReturnsType(r *Runtime) ValueType {
+	return Universe
+}
+
+func (ul *UniverseLiteral) Span() Span {
+	return ul.span
+}
+
+// every variable a universe talks about, in the order they first show up
+func universe_variables(statements []ASTNode) []*GetNode {
+	found := []*GetNode{}
+	seen := map[string]bool{}
+	var walk func(n ASTNode)
+	walk = func(n ASTNode) {
+		switch node := n.(type) {
+		case *GetNode:
+			if !seen[node.name] {
+				seen[node.name] = true
+				found = append(found, node)
+			}
+		case *BinaryOpNode:
+			walk(node.left)
+			walk(node.right)
+		case *NegateNode:
+			walk(node.operand)
+		case *AddAnyNode:
+			walk(node.left)
+			walk(node.right)
+		case *IndexNode:
+			walk(node.target)
+			walk(node.index)
+		case *TupleLiteral:
+			for _, v := range node.values {
+				walk(v)
+			}
+		}
+	}
+	for _, s := range statements {
+		walk(s)
+	}
+	return found
+}
+
+/*
+Solver explores every path through a solve block by rerunning it.
+Each branch point (an unknown variable or an option) asks the solver which way to go,
+the first run takes the first choice everywhere, then the last branch point that still has untried choices is moved along and everything is run again
+*/
+type Solver struct {
+	trail  []branch
+	depth  int  //how many branch points the current run has passed
+	failed bool //a requirement did not hold on this path
+
+	disabled map[*RequireNode]bool //requirements to skip, used when looking for which ones cause there to be no solutions
+}
+
+type branch struct {
+	choice    int
+	options   int
+	is_option bool //false for the branches made when assigning unknown variables
+}
+
+// which of the n choices to take at the next branch point
+func (s *Solver) Choose(n int, is_option bool) int {
+	if s.depth < len(s.trail) {
+		//replaying a path we have been on before
+		c := s.trail[s.depth].choice
+		s.depth++
+		return c
+	}
+	s.trail = append(s.trail, branch{choice: 0, options: n, is_option: is_option})
+	s.depth++
+	return 0
+}
+
+// move on to the next unexplored path, false if there are none left
+func (s *Solver) Advance() bool {
+	for len(s.trail) > 0 {
+		last := &s.trail[len(s.trail)-1]
+		if last.choice+1 < last.options {
+			last.choice++
+			return true
+		}
+		s.trail = s.trail[:len(s.trail)-1]
+	}
+	return false
+}
+
+func (s *Solver) Restart() {
+	s.depth = 0
+	s.failed = false
+}
+
+// the choices taken by options on the current path
+func (s *Solver) OptionChoices() []int {
+	choices := []int{}
+	for _, b := range s.trail[:s.depth] {
+		if b.is_option {
+			choices = append(choices, b.choice)
+		}
+	}
+	return choices
+}
+
+type Solution struct {
+	names    []string
+	values   map[string]Value
+	choices  []int //which alternative each option took, in the order the options were reached
+	trace    []PropagationStep
+	cost     int //value of the minimize/maximize expression
+	has_cost bool
+}
+
+func (*Solution) Name() string {
+	return ""
+}
+func (*Solution) Type() ValueType {
+	return OneSolution
+}
+func (sol *Solution) String() string {
+	s := "{"
+	for i, name := range sol.names {
+		s += fmt.Sprintf("%s: %v", name, sol.values[name])
+		if i < len(sol.names)-1 {
+			s += ", "
+		}
+	}
+	s += "}"
+	if len(sol.choices) > 0 {
+		s += fmt.Sprintf(" options %v", sol.choices)
+	}
+	if sol.has_cost {
+		s += fmt.Sprintf(" cost %d", sol.cost)
+	}
+	for _, step := range sol.trace {
+		s += "\n    " + step.String()
+	}
+	return s
+}
+
+/*
+solve universe, results{
+
+	require universe[i] true
+
+}
+after the name of the results can come, in any order
+limit n - stop after n solutions
+minimize expression / maximize expression - order the solutions by an int expression, best first
+explain - keep a trace of how each variable was worked out
+*/
+type SolveNode struct {
+	universe  string
+	results   string
+	body      *BlockNode
+	explain   bool
+	limit     ASTNode //nil for no limit
+	objective ASTNode //nil if the order does not matter
+	maximize  bool
+
+	span Span
+}
+
+func (sn *SolveNode) Execute(r *Runtime) {
+	limit := -1
+	if sn.limit != nil {
+		sn.limit.Execute(r)
+		l, is_int := r.last_expression_result.(*IntType)
+		r.last_expression_result = nil
+		if !is_int || l.value < 0 {
+			r.throwError(TypeMismatchError, sn.span, "the limit of a solve block must be an int that is not negative")
+			return
+		}
+		limit = l.value
+	}
+	se, ok := sn.new_search(r, map[*RequireNode]bool{})
+	if !ok {
+		return
+	}
+	results := &SolutionSetType{name: sn.results, node: sn, limit: limit}
+	if sn.objective == nil {
+		//solutions are found as they are asked for, but whether there are any has to be known now
+		results.search = se
+		results.At(r, 0)
+	} else {
+		results.solutions = sn.optimize(r, se, limit)
+	}
+	if len(results.solutions) == 0 && limit != 0 && r.last_error == nil {
+		sn.explain_unsatisfiable(r)
+	}
+	r.StackTop().declare(sn.results)
+	r.StackTop().variables[sn.results] = results
+}
+
+// true if a is a better solution than b
+func (sn *SolveNode) better(a, b *Solution) bool {
+	if sn.maximize {
+		return a.cost > b.cost
+	}
+	return a.cost < b.cost
+}
+
+/*
+finds the best solutions by the objective.
+for just the best one with a linear objective each solution found adds the requirement that the next has to beat it,
+otherwise every solution is found and sorted
+*/
+func (sn *SolveNode) optimize(r *Runtime, se *solve_search, limit int) []*Solution {
+	if limit == 0 {
+		return nil
+	}
+	if objective, linear := se.collector.linearize(sn.objective); linear && limit == 1 {
+		best, found := se.Next(r)
+		if !found {
+			return nil
+		}
+		for {
+			//objective <= best - 1 when minimizing, best + 1 <= objective when maximizing
+			bound := LinearConstraint{sum: objective.add(constant_linear(best.cost-1), -1), op: LessEqual}
+			if sn.maximize {
+				bound.sum = constant_linear(best.cost+1).add(objective, -1)
+			}
+			next_se, ok := sn.new_search(r, map[*RequireNode]bool{})
+			if !ok {
+				break
+			}
+			next_se.constraints = append(next_se.constraints, bound)
+			better, found := next_se.Next(r)
+			if !found {
+				break
+			}
+			best = better
+		}
+		return []*Solution{best}
+	}
+	all := []*Solution{}
+	for {
+		sol, found := se.Next(r)
+		if !found {
+			break
+		}
+		all = append(all, sol)
+	}
+	sort.SliceStable(all, func(i, j int) bool { return sn.better(all[i], all[j]) })
+	if limit >= 0 && len(all) > limit {
+		all = all[:limit]
+	}
+	return all
+}
+
+// the requirements at the top of the body, the ones that must hold on every path
+func (sn *SolveNode) requirements() []*RequireNode {
+	reqs := []*RequireNode{}
+	for _, line := range sn.body.lines {
+		if rn, is_require := line.(*RequireNode); is_require {
+			reqs = append(reqs, rn)
+		}
+	}
+	return reqs
+}
+
+/*
+finds a smallest set of requirements that can not all be true at once by taking them away one at a time,
+if the rest still have no solution the one taken away was not needed to cause the problem
+*/
+func (sn *SolveNode) unsatisfiable_core(r *Runtime) []*RequireNode {
+	core := sn.requirements()
+	satisfiable := func(enabled []*RequireNode) bool {
+		disabled := map[*RequireNode]bool{}
+		for _, rn := range sn.requirements() {
+			disabled[rn] = true
+		}
+		for _, rn := range enabled {
+			delete(disabled, rn)
+		}
+		se, ok := sn.new_search(r, disabled)
+		if !ok {
+			return false
+		}
+		_, found := se.Next(r)
+		return found
+	}
+	if !satisfiable([]*RequireNode{}) {
+		//something besides the requirements at the top is to blame, options or requirements nested in them
+		return nil
+	}
+	for i := 0; i < len(core); {
+		without := append(append([]*RequireNode{}, core[:i]...), core[i+1:]...)
+		if satisfiable(without) {
+			i++
+		} else {
+			core = without
+		}
+	}
+	return core
+}
+
+// a solve with no solutions isn't an error, the program carries on with an empty set,
+// so it is a warning with the requirements to blame as notes after it
+func (sn *SolveNode) explain_unsatisfiable(r *Runtime) {
+	r.note(WarningSeverity, "W0301", sn.span, fmt.Sprintf("solving %s has no solutions", sn.universe))
+	core := sn.unsatisfiable_core(r)
+	if len(core) == 0 {
+		if len(sn.requirements()) == 0 {
+			return
+		}
+		r.note(InfoSeverity, "I0302", sn.span, "even with none of the requirements at the top of the block there are no solutions")
+		return
+	}
+	for i, rn := range core {
+		msg := "this requirement can never hold"
+		if len(core) > 1 {
+			msg = fmt.Sprintf("requirement %d of %d that can not all hold at once", i+1, len(core))
+		}
+		r.note(InfoSeverity, "I0301", rn.span, msg)
+	}
+}
+
+func (r *Runtime) note(severity Severity, code string, span Span, msg string) {
+	le := span.Located(code, msg)
+	le.severity = severity
+	r.diagnostics.AddError(le)
+}
+
+// one run through a solve block, asked for solutions one at a time
+type solve_search struct {
+	node        *SolveNode
+	unknowns    []*GetNode
+	names       []string //names of the unknowns, sorted
+	domains     []IntDomain
+	constraints []LinearConstraint
+	solver      *Solver
+	collector   *constraint_collector
+	finished    bool
+
+	//the variables as they were when the search was made, the search can be run much later
+	//after they have changed but every branch still starts from these
+	scope, globals *Scope
+}
+
+// finds what the block is solving for, requirements in disabled are treated as if they were not there
+func (sn *SolveNode) new_search(r *Runtime, disabled map[*RequireNode]bool) (*solve_search, bool) {
+	universe, is_universe := r.StackTop().variables[sn.universe].(*UniverseType)
+	if !is_universe {
+		r.throwError(TypeMismatchError, sn.span, fmt.Sprintf("%s is not a universe", sn.universe))
+		return nil, false
+	}
+	cc := &constraint_collector{r: r, unknowns: map[string]int{}, assigned: assigned_names(sn.body.lines)}
+	se := &solve_search{
+		node:      sn,
+		solver:    &Solver{disabled: disabled},
+		collector: cc,
+		scope:     EmptyScope(),
+		globals:   EmptyScope(),
+	}
+	se.scope.Merge(r.StackTop())
+	se.globals.Merge(r.global_scope)
+	//the unknowns are the variables in the universe that have not been given a value
+	for _, v := range universe_variables(universe.statements) {
+		if r.StackTop().variables[v.name] != nil {
+			continue
+		}
+		switch v.v_type {
+		case Bool:
+			se.domains = append(se.domains, NewIntDomain(0, 1))
+		case Int:
+			domain, has_domain := r.StackTop().domains[v.name]
+			if !has_domain {
+				r.throwError(UnboundedVariableError, sn.span, fmt.Sprintf("can not solve for %s without a range, declare it with var %s int in low..high", v.name, v.name))
+				return nil, false
+			}
+			cc.unknowns[v.name] = len(se.unknowns)
+			se.domains = append(se.domains, domain)
+		default:
+			r.throwError(TypeMismatchError, sn.span, fmt.Sprintf("can not solve for %s of type %v", v.name, v.v_type))
+			return nil, false
+		}
+		se.unknowns = append(se.unknowns, v)
+	}
+	se.names = make([]string, len(se.unknowns))
+	for i := range se.unknowns {
+		se.names[i] = se.unknowns[i].name
+	}
+	sort.Strings(se.names)
+	for _, c := range cc.collect(sn.body) {
+		if !disabled[c.from] {
+			se.constraints = append(se.constraints, c)
+		}
+	}
+	return se, true
+}
+
+// runs the block until it finds the next solution, false once every path has been tried
+func (se *solve_search) Next(r *Runtime) (*Solution, bool) {
+	outer_solver, outer_globals := r.solver, r.global_scope
+	r.solver, r.global_scope = se.solver, se.globals
+	defer func() { r.solver, r.global_scope = outer_solver, outer_globals }()
+	s := se.solver
+	for !se.finished {
+		if !r.step(se.node.span) {
+			se.finished = true
+			return nil, false
+		}
+		s.Restart()
+		branch := EmptyScope()
+		branch.Merge(se.scope)
+		r.scope_stack = append(r.scope_stack, branch)
+		//pick values for the unknowns, narrowing what the rest can be after each pick
+		p := NewPropagator(se.domains, se.constraints)
+		p.tracing = se.node.explain
+		consistent := p.Propagate()
+		for consistent {
+			i := p.SmallestUnassigned()
+			if i == -1 {
+				break
+			}
+			d := p.domains[i]
+			consistent = p.Assign(i, d.Nth(s.Choose(d.Size(), false)))
+		}
+		if consistent {
+			for i, v := range se.unknowns {
+				if v.v_type == Bool {
+					r.StackTop().variables[v.name] = &BoolType{name: v.name, value: p.domains[i].low == 1}
+				} else {
+					r.StackTop().variables[v.name] = &IntType{name: v.name, value: p.domains[i].low}
+				}
+			}
+			se.node.body.Execute(r)
+			if r.last_error != nil {
+				//an error stops the whole program, not just this branch
+				r.PopScope()
+				se.finished = true
+				return nil, false
+			}
+		} else {
+			s.failed = true
+		}
+		found := !s.failed
+		var sol *Solution
+		if found {
+			sol = &Solution{names: se.names, values: map[string]Value{}, choices: s.OptionChoices()}
+			for _, name := range se.names {
+				sol.values[name] = r.StackTop().variables[name]
+			}
+			for _, step := range p.trace {
+				step.variable = se.unknowns[step.unknown].name
+				sol.trace = append(sol.trace, step)
+			}
+			if se.node.objective != nil {
+				se.node.objective.Execute(r)
+				if cost, is_int := r.last_expression_result.(*IntType); is_int {
+					sol.cost = cost.value
+					sol.has_cost = true
+				} else {
+					r.throwError(TypeMismatchError, se.node.span, "the expression to minimize or maximize must be an int")
+				}
+				r.last_expression_result = nil
+			}
+		}
+		r.PopScope()
+		if !s.Advance() {
+			se.finished = true
+		}
+		if found {
+			return sol, true
+		}
+	}
+	return nil, false
+}
+
+// for name in solutions { ... }, solutions are only searched for as the loop gets to them
+type ForInNode struct {
+	variable string
+	set      ASTNode
+	body     *BlockNode
+	span     Span
+}
+
+func (fn *ForInNode) Execute(r *Runtime) {
+	fn.set.Execute(r)
+	set, is_set := r.last_expression_result.(*SolutionSetType)
+	r.last_expression_result = nil
+	if !is_set {
+		r.throwError(TypeMismatchError, fn.span, "can only loop over a solution set")
+		return
+	}
+	r.NewLoopScope(fn.variable)
+	defer r.PopScope()
+	for i := 0; ; i++ {
+		sol, found := set.At(r, i)
+		if !found {
+			return
+		}
+		r.StackTop().next_iteration(fn.variable, sol)
+		fn.body.Execute(r)
+		if r.halted() {
+			return
+		}
+	}
+}
+
+func (*ForInNode) ReturnsType(r *Runtime) ValueType {
+	return NoType
+}
+
+func (fn *ForInNode) Span() Span {
+	return fn.span
+}
+
+// target.name, count and first of a solution set, a variable of a solution or a named element of a tuple
+type PropertyNode struct {
+	target ASTNode
+	name   string
+	span   Span
+}
+
+func (pn *PropertyNode) Execute(r *Runtime) {
+	pn.target.Execute(r)
+	pn.get(r, r.last_expression_result)
+}
+
+func (pn *PropertyNode) get(r *Runtime, target_value Value) {
+	switch target := target_value.(type) {
+	case *SolutionSetType:
+		switch pn.name {
+		case "count":
+			r.last_expression_result = &IntType{value: target.Count(r)}
+			return
+		case "first":
+			if sol, found := target.At(r, 0); found {
+				r.last_expression_result = sol
+				return
+			}
+			r.throwError(IndexOutOfRangeError, pn.span, "first of a solution set with no solutions")
+			return
+		}
+	case *TupleType:
+		if v, exists := target.Element(pn.name); exists {
+			r.last_expression_result = v
+			return
+		}
+	case *Solution:
+		if v, exists := target.values[pn.name]; exists {
+			r.last_expression_result = v
+			return
+		}
+		if pn.name == "cost" {
+			r.last_expression_result = &IntType{value: target.cost}
+			return
+		}
+	}
+	r.throwError(MissingOverloadError, pn.span, fmt.Sprintf("%v has no %s", target_value, pn.name))
+}
+
+func (pn *PropertyNode) ReturnsType(r *Runtime) ValueType {
+	switch pn.name {
+	case "count", "cost":
+		return Int
+	case "first":
+		return OneSolution
+	}
+	return NoType
+}
+
+func (pn *PropertyNode) Span() Span {
+	return pn.span
+}
+
+func (*SolveNode) ReturnsType(r *Runtime) ValueType {
+	return NoType
+}
+
+func (sn *SolveNode) Span() Span {
+	return sn.span
+}
+
+/*
+option {
+
+	a = 1
+
+} {
+
+	a = 2
+
+}
+outside of a solve block the first alternative is taken, inside one each alternative is a different branch
+*/
+type OptionNode struct {
+	alternatives []*BlockNode
+	span         Span
+}
+
+func (on *OptionNode) Execute(r *Runtime) {
+	if r.solver == nil {
+		on.alternatives[0].Execute(r)
+		return
+	}
+	choice := r.solver.Choose(len(on.alternatives), true)
+	on.alternatives[choice].Execute(r)
+}
+
+func (*OptionNode) ReturnsType(r *Runtime) ValueType {
+	return NoType
+}
+
+func (on *OptionNode) Span() Span {
+	return on.span
+}
+
+// require condition, inside a solve block a false condition throws away the current branch
+type RequireNode struct {
+	condition ASTNode
+	span      Span
+}
+
+func (rn *RequireNode) Execute(r *Runtime) {
+	if r.solver != nil && r.solver.disabled[rn] {
+		return
+	}
+	rn.condition.Execute(r)
+	if r.halted() {
+		return
+	}
+	holds := false
+	if b, is_bool := r.last_expression_result.(*BoolType); is_bool {
+		holds = b.value
+	}
+	r.last_expression_result = nil
+	if holds {
+		return
+	}
+	if r.solver != nil {
+		r.solver.failed = true
+		return
+	}
+	r.throwError(RequirementError, rn.span, "requirement not met")
+}
+
+func (*RequireNode) ReturnsType(r *Runtime) ValueType {
+	return NoType
+}
+
+func (rn *RequireNode) Span() Span {
+	return rn.span
+}
@@ -0,0 +1,136 @@
+package lang
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLoopBodyWritesThrough(t *testing.T) {
+	solutions := "var i int in 1..4\nvar u universe = (i)\nsolve u, s {\n}\n"
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"var total int = 0\nfor n in s {\n\ttotal = total + n.i\n}\nprint total", "10\n"},
+		{"var last int = 0\nfor n in s {\n\tlast = n.i\n}\nprint last", "4\n"},
+		//the loop variable and what the body declares stay in the loop
+		{"var n int = 7\nfor n in s {\n\tn = n.i\n}\nprint n", "7\n"},
+		{"var x int = 7\nfor n in s {\n\tvar x int\n\tx = n.i\n}\nprint x", "7\n"},
+		//the loop's scope is used again each time round, but what the body declared doesn't carry over
+		{"var x int = 7\nfor n in s {\n\tprint x\n\tvar x int = n.i\n}\nprint x", "7\n7\n7\n7\n7\n"},
+		{"var pairs int = 0\nfor a in s {\n\tfor b in s {\n\t\tpairs = pairs + 1\n\t}\n}\nprint pairs", "16\n"},
+	}
+	for _, test := range tests {
+		if got := output_of(t, solutions+test.src); got != test.want {
+			t.Errorf("%q: printed %q, want %q", test.src, got, test.want)
+		}
+	}
+}
+
+// the codes of what running src reported, in order
+func run_codes(t *testing.T, src string) []string {
+	t.Helper()
+	prog, diags := CompileReader(strings.NewReader(src), CompileOptions{})
+	if prog == nil {
+		t.Fatalf("%q didn't compile: %v", src, diags)
+	}
+	res, _ := prog.Run(context.Background(), RunOptions{})
+	codes := []string{}
+	for _, d := range res.Diagnostics {
+		codes = append(codes, d.Severity+" "+d.Code)
+	}
+	return codes
+}
+
+func TestUnsatisfiableNotes(t *testing.T) {
+	header := "var k int = 0\nvar i int in 0..5\nvar u universe = (i)\n"
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{"\trequire i > 3\n\trequire i < 2\n", []string{"warning W0301", "info I0301", "info I0301"}},
+		{"\trequire i > 9\n\trequire i < 2\n", []string{"warning W0301", "info I0301"}},
+		{"\trequire i > 1\n\toption {\n\t\trequire i > 9\n\t}\n", []string{"warning W0301", "info I0302"}},
+		//k is 2 by the time the requirement runs, there are solutions and nothing to blame
+		{"\tk = 2\n\trequire i == k\n", []string{}},
+		{"\tk = 2\n\trequire i == k\n\trequire i > 3\n", []string{"warning W0301", "info I0301", "info I0301"}},
+	}
+	for _, test := range tests {
+		src := header + "solve u, s {\n" + test.body + "}\nprint s"
+		if got := run_codes(t, src); strings.Join(got, ", ") != strings.Join(test.want, ", ") {
+			t.Errorf("%q: reported %v, want %v", test.body, got, test.want)
+		}
+	}
+}
+
+func TestOption(t *testing.T) {
+	universe := "var x int in 0..9\nvar y int in 0..9\nvar u universe = (x, y)\n"
+	tests := []struct {
+		src  string
+		want string
+	}{
+		//outside a solve block the first alternative is taken
+		{"var a int = 0\noption {\n\ta = 1\n} {\n\ta = 2\n}\nprint a", "1\n"},
+		{"var a int = 0\noption {\n\ta = 1\n}\nprint a", "1\n"},
+		//inside one each alternative is a branch of the search, and the solution remembers which
+		{universe + "solve u, s limit 4 {\n\trequire x == 1\n\trequire y < 2\n\toption {\n\t\trequire y == 0\n\t} {\n\t\trequire y == 1\n\t}\n}\nprint s",
+			"{x: 1, y: 0} options [0]\n{x: 1, y: 1} options [1]\n"},
+		{universe + "solve u, s {\n\trequire x == 1\n\trequire y < 2\n\toption {\n\t\trequire y == 0\n\t} {\n\t\trequire y == 0\n\t}\n}\nprint s.count",
+			"2\n"},
+		{universe + "solve u, s {\n\trequire x == 1\n\trequire y == 1\n\toption {\n\t\toption {\n\t\t} {\n\t\t}\n\t} {\n\t}\n}\nprint s",
+			"{x: 1, y: 1} options [0 0]\n{x: 1, y: 1} options [0 1]\n{x: 1, y: 1} options [1]\n"},
+	}
+	for _, test := range tests {
+		if got := output_of(t, test.src); got != test.want {
+			t.Errorf("%q: printed %q, want %q", test.src, got, test.want)
+		}
+	}
+}
+
+func TestSolutionSets(t *testing.T) {
+	universe := "var x int in 0..9\nvar y int in 0..9\nvar u universe = (x, y)\n"
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"solve u, s {\n\trequire x + y == 3\n}\nprint s.count\nprint s.first\nprint s[2].y", "4\n{x: 0, y: 3}\n1\n"},
+		{"solve u, s limit 2 {\n\trequire x + y == 3\n}\nprint s", "{x: 0, y: 3}\n{x: 1, y: 2}\n"},
+		{"solve u, s limit 0 {\n}\nprint s.count", "0\n"},
+		{"solve u, s limit 3 minimize x * 2 - y {\n\trequire x + y >= 10\n}\nprint s\nprint s.first.cost",
+			"{x: 1, y: 9} cost -7\n{x: 2, y: 9} cost -5\n{x: 2, y: 8} cost -4\n-7\n"},
+		{"solve u, s maximize x + y {\n\trequire x + y < 5\n\trequire x > y\n}\nprint s.count\nprint s.first", "6\n{x: 3, y: 1} cost 4\n"},
+		//the best one on its own is found by tightening the bound instead of sorting everything
+		{"solve u, s limit 1 maximize x * 3 + y {\n}\nprint s", "{x: 9, y: 9} cost 36\n"},
+		{"solve u, s limit 1 minimize x * y {\n\trequire x > 2\n\trequire y > 2\n}\nprint s", "{x: 3, y: 3} cost 9\n"},
+		{"solve u, s {\n\trequire x + y == 3\n}\nfor a in s {\n\tprint a.x * 10 + a.y\n}", "3\n12\n21\n30\n"},
+		{"solve u, s {\n\trequire x > 9\n}\nprint s\nprint s.count", "no solutions\n0\n"},
+	}
+	for _, test := range tests {
+		if got := output_of(t, universe+test.src); got != test.want {
+			t.Errorf("%q: printed %q, want %q", test.src, got, test.want)
+		}
+	}
+	//solutions are only searched for as they are asked for, the first of a huge universe is quick
+	huge := "var a int in 0..999\nvar b int in 0..999\nvar c int in 0..999\nvar h universe = (a, b, c)\nsolve h, s {\n\trequire a + b + c > 2\n}\nprint s.first"
+	prog, _ := Compile(huge)
+	var out strings.Builder
+	for _, vm := range []bool{false, true} {
+		out.Reset()
+		if _, err := prog.Run(context.Background(), RunOptions{Output: &out, VM: vm, Limits: Limits{Steps: 10000}}); err != nil || out.String() != "{a: 0, b: 0, c: 3}\n" {
+			t.Errorf("vm %v: printed %q, %v", vm, out.String(), err)
+		}
+	}
+}
+
+func TestExplainSolutions(t *testing.T) {
+	src := "var x int in 0..9\nvar y int in 0..9\nvar u universe = (x, y)\nsolve u, s explain {\n\trequire x == 3\n\trequire y > x\n}\nprint s.first"
+	want := "{x: 3, y: 4}\n    x narrowed from 0..9 to 3 by line 5\n    y narrowed from 0..9 to 4..9 by line 6\n    y picked 4\n"
+	if got := output_of(t, src); got != want {
+		t.Errorf("printed %q, want %q", got, want)
+	}
+	//without explain there is no trace kept
+	if got := output_of(t, strings.Replace(src, " explain", "", 1)); got != "{x: 3, y: 4}\n" {
+		t.Errorf("without explain printed %q", got)
+	}
+}
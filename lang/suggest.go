@@ -0,0 +1,107 @@
+package lang
+
+import (
+	"fmt"
+	"sort"
+)
+
+// a change to the source that fixes an error, replacing start..end (bytes) on line with replacement
+// tools can apply these without needing to understand the error
+type FixIt struct {
+	line        int
+	start, end  int
+	replacement string
+}
+
+func (f FixIt) String() string {
+	return fmt.Sprintf("%d:%d-%d => %q", f.line, f.start, f.end, f.replacement)
+}
+
+// line_src with the fix applied, it is up to the caller to give it the right line
+func (f FixIt) Apply(line_src string) string {
+	if f.start > len(line_src) || f.end > len(line_src) || f.start > f.end {
+		return line_src
+	}
+	return line_src[:f.start] + f.replacement + line_src[f.end:]
+}
+
+// adds a "did you mean" to the error, with a fix that swaps what is at fix.start..fix.end for it
+func (le LocatedError) WithFix(suggestion string, fix FixIt) LocatedError {
+	le.suggestion = suggestion
+	le.fix = &fix
+	return le
+}
+
+// if one of candidates is close enough to what tok says to be a typo of it, suggest replacing tok with it
+func (le LocatedError) Suggest(tok Token, candidates []string) LocatedError {
+	if le.index == tok.index_start && le.line == tok.line {
+		le.end = tok.index_end
+	}
+	closest, found := closest_name(tok.text, candidates)
+	if !found {
+		return le
+	}
+	return le.WithFix(closest, FixIt{line: tok.line, start: tok.index_start, end: tok.index_end, replacement: closest})
+}
+
+// the candidate with the smallest edit distance from name, as long as it is small enough to be a typo
+func closest_name(name string, candidates []string) (string, bool) {
+	sorted := append([]string{}, candidates...)
+	sort.Strings(sorted) //so ties always go the same way
+	best := ""
+	best_distance := len(name)/3 + 1 //more than this and it is probably a different word
+	for _, candidate := range sorted {
+		if candidate == name {
+			continue
+		}
+		if d := edit_distance(name, candidate); d < best_distance {
+			best = candidate
+			best_distance = d
+		}
+	}
+	return best, best != ""
+}
+
+// how many characters have to be added, removed, changed or swapped with their neighbour to turn a into b
+func edit_distance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	d := make([][]int, len(ar)+1)
+	for i := range d {
+		d[i] = make([]int, len(br)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			d[i][j] = min_int(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ar[i-1] == br[j-2] && ar[i-2] == br[j-1] {
+				d[i][j] = min_int(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ar)][len(br)]
+}
+
+func min_int(first int, rest ...int) int {
+	for _, v := range rest {
+		if v < first {
+			first = v
+		}
+	}
+	return first
+}
+
+// every name a map has, for feeding to Suggest
+func names_of[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	return names
+}
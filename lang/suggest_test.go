@@ -0,0 +1,65 @@
+package lang
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSuggestions(t *testing.T) {
+	tests := []struct {
+		src      string
+		want     string
+		fix_line string //the line with the fix applied
+	}{
+		{"var total int = 1\nprint totl", "total", "print total"},
+		{"print tru", "true", "print true"},
+		{"var b bool = fasle", "false", "var b bool = false"},
+		{"var x int = 1\nprint retrun", "return", "print return"},
+		{"retrun 1", "return", "return 1"},
+		{"pirnt 1", "print", "print 1"},
+		{"func double(n int) int {\n\treturn n * 2\n}\nprint dobule(2)", "double", "print double(2)"},
+		{"var total int = 1\ntotl = 2", "total", "total = 2"},
+		{"var é int = 1\nprint tru", "true", "print true"},
+	}
+	for _, test := range tests {
+		_, diags := CompileReader(strings.NewReader(test.src), CompileOptions{})
+		var found *Diagnostic
+		for i := range diags {
+			if diags[i].Suggestion != "" {
+				found = &diags[i]
+				break
+			}
+		}
+		if found == nil || found.Suggestion != test.want {
+			t.Errorf("%q: got %v, want a suggestion of %s", test.src, diags, test.want)
+			continue
+		}
+		lines := strings.Split(test.src, "\n")
+		line := []rune(lines[found.Fix.Line-1])
+		fixed := string(line[:found.Fix.Column-1]) + found.Fix.Replacement + string(line[found.Fix.EndColumn-1:])
+		if fixed != test.fix_line {
+			t.Errorf("%q: the fix gives %q, want %q", test.src, fixed, test.fix_line)
+		}
+	}
+}
+
+func TestClosestName(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []string
+		want       string
+	}{
+		{"tru", []string{"true", "false"}, "true"},
+		{"teh", []string{"the"}, "the"},
+		{"x", []string{"y"}, ""},
+		{"total", []string{"total"}, ""},
+		{"completely", []string{"different"}, ""},
+		//ties go to whichever sorts first
+		{"abcd", []string{"abce", "abca"}, "abca"},
+	}
+	for _, test := range tests {
+		if got, _ := closest_name(test.name, test.candidates); got != test.want {
+			t.Errorf("closest to %q in %v is %q, want %q", test.name, test.candidates, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,602 @@
+package lang
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// splits src_txt into tokens line by line, anything it can not make sense of goes into errs
+func Tokenize(src_txt string, file string, errs *ErrorCollector) [][]Token {
+	lx := NewLexer(strings.NewReader(src_txt), file, errs)
+	token_lines := [][]Token{}
+	for {
+		toks, ok := lx.NextLine()
+		if !ok {
+			return token_lines
+		}
+		token_lines = append(token_lines, toks)
+	}
+}
+
+type LineTokenizer struct {
+	line_src string
+	index    int
+	line_num int
+	file     string
+	errs     *ErrorCollector
+	open_raw *Token //a raw string that hasn't been closed yet, it can go over many lines
+
+	comment_depth int //how many /* are open, carries on to the next line
+}
+
+// reports an error at index on this line, if stop_line the rest of the line is skipped since it can't be trusted
+func (lp *LineTokenizer) throwError(code string, msg string, index int, stop_line bool) {
+	lp.report(NewLocatedError(code, lp.line_num, index, msg), stop_line)
+}
+func (lp *LineTokenizer) report(le LocatedError, stop_line bool) {
+	le.file = lp.file
+	lp.errs.AddError(le)
+	if stop_line {
+		lp.index = len(lp.line_src)
+	}
+}
+func (lp *LineTokenizer) Rest() string {
+	t := lp.line_src[lp.index:]
+	lp.index = len(lp.line_src)
+	return t
+}
+
+// reads a /* block comment */ from the current index, they nest so /* /* */ */ is one comment
+// if the line runs out first lp.comment_depth says how many are still open
+func (lp *LineTokenizer) ParseBlockComment() string {
+	start := lp.index
+	for lp.HasNext() && lp.comment_depth > 0 {
+		if lp.NextIs("/*") {
+			lp.comment_depth++
+			lp.index += 2
+		} else if lp.NextIs("*/") {
+			lp.comment_depth--
+			lp.index += 2
+		} else {
+			lp.ConsumeNext()
+		}
+	}
+	return lp.line_src[start:lp.index]
+}
+
+// reads a whole number literal starting at the current index, 1_000 0x1F 0o17 0b101 1.5 .5 2e-3
+// ok is false if it was malformed, the error has already been reported then
+func (lp *LineTokenizer) ParseNumber() (text string, is_float bool, ok bool) {
+	start := lp.index
+	prefixed := false
+	for _, prefix := range []string{"0x", "0X", "0o", "0O", "0b", "0B"} {
+		if lp.NextIs(prefix) {
+			prefixed = true
+			lp.index += 2
+			break
+		}
+	}
+scan:
+	for lp.HasNext() {
+		c := lp.line_src[lp.index]
+		switch {
+		case c == '.':
+			//1..9 is a range not a number
+			if prefixed || lp.index+1 >= len(lp.line_src) || !is_digit(lp.line_src[lp.index+1]) {
+				break scan
+			}
+			is_float = true
+		case (c == 'e' || c == 'E') && !prefixed:
+			is_float = true
+			if lp.index+1 < len(lp.line_src) && (lp.line_src[lp.index+1] == '+' || lp.line_src[lp.index+1] == '-') {
+				lp.index++
+			}
+		case c == '_' || is_digit(c) || is_letter(c):
+			//letters are swallowed so 12abc is one bad number, not a number and a name
+		case c >= utf8.RuneSelf && unicode.IsLetter(first_rune(lp.line_src[lp.index:])):
+			lp.index += len(lp.PeekNext()) - 1
+		default:
+			break scan
+		}
+		lp.index++
+	}
+	text = lp.line_src[start:lp.index]
+
+	if is_float {
+		_, err := strconv.ParseFloat(text, 64)
+		if err != nil && errors.Is(err, strconv.ErrRange) {
+			lp.throwError("E0006", fmt.Sprintf("float literal %s is out of range", text), start, false)
+			return text, true, false
+		} else if err != nil {
+			lp.throwError("E0005", fmt.Sprintf("%s is not a valid number", text), start, false)
+			return text, true, false
+		}
+		return text, true, true
+	}
+	if !prefixed && len(text) > 1 && text[0] == '0' {
+		lp.throwError("E0005", fmt.Sprintf("%s has a leading zero, write 0o%s for an octal number", text, strings.TrimLeft(text, "0_")), start, false)
+		return text, false, false
+	}
+	_, err := int_literal_value(text)
+	if err != nil && errors.Is(err, strconv.ErrRange) {
+		lp.throwError("E0006", fmt.Sprintf("integer literal %s does not fit in 64 bits", text), start, false)
+		return text, false, false
+	} else if err != nil {
+		lp.throwError("E0005", fmt.Sprintf("%s is not a valid number", text), start, false)
+		return text, false, false
+	}
+	return text, false, true
+}
+
+// the magnitude of an int literal, which can be one past the biggest int so -9223372036854775808 can be written
+func int_literal_value(text string) (uint64, error) {
+	value, err := strconv.ParseUint(text, 0, 64)
+	if err == nil && value > 1<<63 {
+		return value, &strconv.NumError{Func: "ParseUint", Num: text, Err: strconv.ErrRange}
+	}
+	return value, err
+}
+
+func is_digit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+func is_letter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// reads the rest of a name, any unicode letters, digits and _
+func (lp *LineTokenizer) ParseText(initial string) string {
+	sofar := initial
+	for lp.HasNext() {
+		next, _ := utf8.DecodeRuneInString(lp.line_src[lp.index:])
+		if !is_name_rune(next) {
+			break
+		} else {
+			sofar += lp.ConsumeNext()
+		}
+	}
+	return sofar
+}
+
+func is_name_rune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// reads up to and including the closing ", ok is false if there wasn't one
+func (lp *LineTokenizer) ParseQuotedText() (string, bool) {
+	sofar := ""
+	for lp.HasNext() {
+		next := lp.PeekNext()
+		if next == "\"" {
+			lp.ConsumeNext()
+			return sofar, true
+		} else if next == "\\" {
+			sofar += lp.ParseEscape()
+		} else {
+			sofar += lp.ConsumeNext()
+		}
+	}
+	//ran out of text and no ""
+	return "", false
+}
+
+// reads one escape sequence starting at the \, bad ones are reported and come out as ""
+func (lp *LineTokenizer) ParseEscape() string {
+	start := lp.index
+	lp.ConsumeNext() // \
+	if !lp.HasNext() {
+		//the string has no end either, that gets reported instead
+		return ""
+	}
+	switch special := lp.ConsumeNext(); special {
+	case "n":
+		return "\n"
+	case "t":
+		return "\t"
+	case "r":
+		return "\r"
+	case "0":
+		return "\x00"
+	case "\\", "\"":
+		return special
+	case "x":
+		// \x41, only ascii since anything bigger is not a whole character
+		if lp.index+2 > len(lp.line_src) {
+			lp.throwError("E0004", "\\x needs two hex digits", start, false)
+			lp.index = len(lp.line_src)
+			return ""
+		}
+		digits := lp.line_src[lp.index : lp.index+2]
+		value, err := strconv.ParseUint(digits, 16, 8)
+		if err != nil {
+			lp.throwError("E0004", fmt.Sprintf("\\x needs two hex digits, not %s", digits), start, false)
+			return ""
+		}
+		lp.index += 2
+		if value > 0x7F {
+			lp.throwError("E0004", fmt.Sprintf("\\x%s is not ascii, use \\u{%s} for that character", digits, digits), start, false)
+			return ""
+		}
+		return string(rune(value))
+	case "u":
+		// \u{1F600}
+		if !lp.NextIs("{") {
+			lp.throwError("E0004", "expected `{` after \\u", start, false)
+			return ""
+		}
+		end := strings.Index(lp.line_src[lp.index:], "}")
+		if end == -1 {
+			lp.throwError("E0004", "\\u{ is missing its closing `}`", start, false)
+			return ""
+		}
+		digits := lp.line_src[lp.index+1 : lp.index+end]
+		lp.index += end + 1
+		value, err := strconv.ParseUint(digits, 16, 32)
+		if err != nil || len(digits) > 6 || !utf8.ValidRune(rune(value)) {
+			lp.throwError("E0004", fmt.Sprintf("\\u{%s} is not a unicode character", digits), start, false)
+			return ""
+		}
+		return string(rune(value))
+	default:
+		lp.throwError("E0004", fmt.Sprintf("unknown escape \\%s", special), start, false)
+		return ""
+	}
+}
+
+// reads a `raw string` from after its opening backtick, nothing is escaped in it
+// if the line runs out first it stays open in lp.open_raw for the next line to finish
+func (lp *LineTokenizer) ParseRawText(sofar string) (string, bool) {
+	end := strings.Index(lp.line_src[lp.index:], "`")
+	if end == -1 {
+		sofar += lp.line_src[lp.index:]
+		lp.index = len(lp.line_src)
+		return sofar, false
+	}
+	sofar += lp.line_src[lp.index : lp.index+end]
+	lp.index += end + 1
+	return sofar, true
+}
+
+// the next character, which can be more than one byte
+func (lp *LineTokenizer) PeekNext() string {
+	_, size := utf8.DecodeRuneInString(lp.line_src[lp.index:])
+	return lp.line_src[lp.index : lp.index+size]
+}
+func (lp *LineTokenizer) ConsumeNext() string {
+	s := lp.PeekNext()
+	lp.index += len(s)
+	return s
+}
+func (lp *LineTokenizer) HasNext() bool {
+	return lp.index < len(lp.line_src)
+}
+
+// every operator and punctuation token, Parse always takes the longest one that matches so ... beats .. beats .
+var operator_tokens = []struct {
+	text string
+	TokenType
+}{
+	{"...", Ellipsis},
+	{"..", DotDot},
+	{"==", Equality},
+	{"!=", NotEqual},
+	{"<=", LessEqual},
+	{">=", GreaterEqual},
+	{"&&", And},
+	{"||", Or},
+	{"++", PlusPlus},
+	{"--", MinusMinus},
+	{"+=", PlusAssign},
+	{"-=", MinusAssign},
+	{"*=", MultiplyAssign},
+	{"/=", DivideAssign},
+	{"->", Arrow},
+	{"=", Assignment},
+	{"!", Not},
+	{"&", Reference},
+	{"+", Plus},
+	//a - is always minus here, whether its actually negation is up to the tree maker
+	{"-", Minus},
+	{"*", Multiply},
+	{"/", Divide},
+	{"%", Modulo},
+	{"<", OpenAlligator},
+	{">", CloseAlligator},
+	{"(", OpenParen},
+	{")", CloseParen},
+	{"[", OpenSquare},
+	{"]", CloseSquare},
+	{"{", OpenCurly},
+	{"}", CloseCurly},
+	{",", Comma},
+	{";", Semicolon},
+	{":", Colon},
+	{".", Dot},
+}
+
+// the longest operator at the current index, ok is false if there isn't one
+func (lp *LineTokenizer) ParseOperator() (Token, bool) {
+	rest := lp.line_src[lp.index:]
+	best := -1
+	for i, op := range operator_tokens {
+		if strings.HasPrefix(rest, op.text) && (best == -1 || len(op.text) > len(operator_tokens[best].text)) {
+			best = i
+		}
+	}
+	if best == -1 {
+		return Token{}, false
+	}
+	op := operator_tokens[best]
+	tok := Token{TokenType: op.TokenType, text: op.text, index_start: lp.index, index_end: lp.index + len(op.text)}
+	lp.index += len(op.text)
+	return tok, true
+}
+
+// true if the line continues with text at the current index
+func (lp *LineTokenizer) NextIs(text string) bool {
+	return strings.HasPrefix(lp.line_src[lp.index:], text)
+}
+
+func (lp *LineTokenizer) Parse() []Token {
+	toks := []Token{}
+	if lp.open_raw != nil {
+		//still inside a raw string from an earlier line
+		txt, closed := lp.ParseRawText(lp.open_raw.text + "\n")
+		lp.open_raw.text = txt
+		if closed {
+			lp.open_raw.last_line = lp.line_num
+			toks = append(toks, *lp.open_raw)
+			lp.open_raw = nil
+		}
+	}
+	if lp.comment_depth > 0 {
+		comment_src := lp.ParseBlockComment()
+		tok := Token{TokenType: Comment_TType, text: comment_src, line: lp.line_num, index_start: 0, index_end: lp.index}
+		lp.SetColumns(&tok)
+		toks = append(toks, tok)
+	}
+	for lp.HasNext() {
+		start := lp.index
+		tok := Token{}
+		s := lp.PeekNext()
+		switch {
+		case s == " " || s == "\t":
+			lp.ConsumeNext()
+			continue
+		case lp.NextIs("///") && !lp.NextIs("////"):
+			//documents whatever is declared on the next line
+			lp.index += 3
+			doc_src := lp.Rest()
+			tok = Token{TokenType: DocComment_TType, text: strings.TrimSpace(doc_src), index_start: start, index_end: lp.index}
+		case lp.NextIs("/*"):
+			lp.index += 2
+			lp.comment_depth = 1
+			lp.ParseBlockComment()
+			tok = Token{TokenType: Comment_TType, text: lp.line_src[start:lp.index], index_start: start, index_end: lp.index}
+		case lp.NextIs("*/"):
+			lp.index += 2
+			lp.throwError("E0003", "`*/` without a `/*` to close", start, false)
+			continue
+		case lp.NextIs("//"):
+			lp.index += 2
+			comment_src := lp.Rest()
+			tok = Token{TokenType: Comment_TType, text: comment_src, index_start: start, index_end: lp.index}
+		case s == "`":
+			lp.ConsumeNext()
+			txt, closed := lp.ParseRawText("")
+			tok = Token{TokenType: StringLiteral_TType, text: txt, index_start: start, index_end: lp.index}
+			if !closed {
+				tok.line = lp.line_num
+				lp.SetColumns(&tok)
+				lp.open_raw = &tok
+				continue
+			}
+		case s == "\"":
+			lp.ConsumeNext()
+			txt, closed := lp.ParseQuotedText()
+			if !closed {
+				lp.throwError("E0002", "string is missing its closing `\"`", start, true)
+				continue
+			}
+			tok = Token{TokenType: StringLiteral_TType, text: txt, index_start: start, index_end: lp.index}
+		case is_digit(s[0]) || (s == "." && lp.index+1 < len(lp.line_src) && is_digit(lp.line_src[lp.index+1])):
+			//.2 is a number too
+			//a bad number has already been reported, it still goes in as a number so the parser doesn't complain again
+			src, is_float, _ := lp.ParseNumber()
+			tok = Token{TokenType: IntLiteral_TType, text: src, index_start: start, index_end: lp.index}
+			if is_float {
+				tok.TokenType = FloatLiteral_TType
+			}
+		case s == "_" || unicode.IsLetter(first_rune(s)):
+			//is the start of text, names can start with _ like __add__
+			txt := lp.ParseText(lp.ConsumeNext())
+			tok = TextToken(txt)
+			tok.index_start = start
+			tok.index_end = lp.index
+		default:
+			op, ok := lp.ParseOperator()
+			if !ok {
+				//skip it and carry on, the rest of the line might still be fine
+				lp.ConsumeNext()
+				if s == "|" {
+					fix := FixIt{line: lp.line_num, start: start, end: start + 1, replacement: "||"}
+					lp.report(NewLocatedError("E0001", lp.line_num, start, "no such operator `|`").WithFix("||", fix), false)
+				} else if first_rune(s) == utf8.RuneError {
+					lp.throwError("E0001", fmt.Sprintf("invalid UTF-8 byte %#x", s[0]), start, false)
+				} else {
+					lp.throwError("E0001", fmt.Sprintf("unknown character %q", s), start, false)
+				}
+				continue
+			}
+			tok = op
+		}
+
+		tok.line = lp.line_num
+		lp.SetColumns(&tok)
+		toks = append(toks, tok)
+	}
+	return toks
+}
+
+// works out the character columns of a token from its byte indexes
+func (lp *LineTokenizer) SetColumns(tok *Token) {
+	tok.column_start = utf8.RuneCountInString(lp.line_src[:tok.index_start])
+	tok.column_end = tok.column_start + utf8.RuneCountInString(lp.line_src[tok.index_start:tok.index_end])
+}
+
+func first_rune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+// every word that means something to the language, none of these can be used as a name
+var keywords = map[string]TokenType{
+	"var":      Var_TType,
+	"const":    Const_TType,
+	"type":     Type_TType,
+	"print":    Print_TType,
+	"vec":      Vec_TType,
+	"int":      BuiltinType_TType,
+	"float":    BuiltinType_TType,
+	"string":   BuiltinType_TType,
+	"bool":     BuiltinType_TType,
+	"tuple":    BuiltinType_TType,
+	"universe": BuiltinType_TType,
+	"true":     True_TType,
+	"false":    False_TType,
+	"func":     Func_TType,
+	"return":   Return_TType,
+	"if":       If_TType,
+	"elif":     Elif_TType,
+	"else":     Else_TType,
+	"for":      For_TType,
+	"while":    While_TType,
+	"in":       In_TType,
+	"solve":    Solve_TType,
+	"option":   Option_TType,
+	"require":  Require_TType,
+}
+
+func TextToken(txt string) Token {
+	if keyword, is_keyword := keywords[txt]; is_keyword {
+		return Token{TokenType: keyword, text: txt}
+	}
+	return Token{
+		TokenType: Name_TType,
+		text:      txt,
+	}
+}
+
+type Token struct {
+	TokenType
+	text                     string
+	line                     int
+	index_start, index_end   int //bytes into the line
+	column_start, column_end int //characters into the line, what a person counting would get
+	last_line                int //the line a raw string going over many lines ends on, 0 if it is all on line
+}
+
+// the line the token ends on
+func (t Token) end_line() int {
+	if t.last_line > t.line {
+		return t.last_line
+	}
+	return t.line
+}
+
+func (t Token) String() string {
+	return fmt.Sprintf("%s:%s", &t.TokenType, t.text)
+}
+func (t TokenType) String() string {
+	names := []string{"Unknown_TType", "Var_TType", "Name_TType", "IntLiteral_TType", "FloatLiteral_TType", "StringLiteral_TType", "Vec_TType", "BuiltinType_TType", "Print_TType", "Comment_TType", "DocComment_TType", "Solve_TType", "Option_TType", "Require_TType", "In_TType", "For_TType", "Func_TType", "Return_TType", "Const_TType", "Type_TType", "True_TType", "False_TType", "If_TType", "Elif_TType", "Else_TType", "While_TType", "OpenAlligator", "CloseAlligator", "OpenParen", "CloseParen", "OpenCurly", "CloseCurly", "OpenSquare", "CloseSquare", "Comma", "Dot", "DotDot", "Assignment", "Equality", "NotEqual", "LessEqual", "GreaterEqual", "Plus", "Minus", "Multiply", "Divide", "Modulo", "PlusPlus", "MinusMinus", "PlusAssign", "MinusAssign", "MultiplyAssign", "DivideAssign", "Reference", "Not", "Or", "And", "Semicolon", "Colon", "Ellipsis", "Arrow"}
+	return names[t]
+}
+
+// how t is written in a program, + instead of Plus, for messages meant for whoever wrote it
+func (t TokenType) Lexeme() string {
+	for _, op := range operator_tokens {
+		if op.TokenType == t {
+			return op.text
+		}
+	}
+	return t.String()
+}
+
+/*
+var a int = 12
+Var Name IntType Assignment IntLiteral
+*/
+
+type TokenType int
+
+const (
+	Unknown_TType       TokenType = iota
+	Var_TType                     //var
+	Name_TType                    // var_name
+	IntLiteral_TType              // 1, 0x1F, 1_000
+	FloatLiteral_TType            // 1e23, 0.231, .5
+	StringLiteral_TType           //"wow"
+	Vec_TType                     //vec
+	BuiltinType_TType             //int, string, etc
+	Print_TType                   //print
+	Comment_TType                 // // or /* */
+	DocComment_TType              // ///
+	Solve_TType                   //solve
+	Option_TType                  //option
+	Require_TType                 //require
+	In_TType                      //in
+	For_TType                     //for
+	Func_TType                    //func
+	Return_TType                  //return
+	Const_TType                   //const
+	Type_TType                    //type
+	True_TType                    //true
+	False_TType                   //false
+	If_TType                      //if
+	Elif_TType                    //elif
+	Else_TType                    //else
+	While_TType                   //while
+	//Brackets
+	OpenAlligator
+	CloseAlligator
+	OpenParen
+	CloseParen
+	OpenCurly
+	CloseCurly
+	OpenSquare
+	CloseSquare
+	Comma
+	Dot
+	DotDot // ..
+	//Operators
+	Assignment     //=
+	Equality       //==
+	NotEqual       //!=
+	LessEqual      //<=
+	GreaterEqual   //>=
+	Plus           //+
+	Minus          //-
+	Multiply       //*
+	Divide         // /
+	Modulo         //%
+	PlusPlus       //++
+	MinusMinus     //--
+	PlusAssign     //+=
+	MinusAssign    //-=
+	MultiplyAssign //*=
+	DivideAssign   // /=
+	Reference      //&
+	Not            //!
+	Or             //||
+	And            //&&
+	//Punctuation
+	Semicolon //;
+	Colon     //:
+	Ellipsis  //...
+	Arrow     //->
+
+)
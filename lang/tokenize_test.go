@@ -0,0 +1,297 @@
+package lang
+
+import (
+	"reflect"
+	"testing"
+)
+
+// the tokens on the first line of src, failing the test if anything couldn't be tokenized
+func tokens_of(t *testing.T, src string) []Token {
+	t.Helper()
+	errs := &ErrorCollector{}
+	lines := Tokenize(src, "test", errs)
+	if len(errs.errs) > 0 {
+		t.Fatalf("%q: %v", src, errs.errs)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return lines[0]
+}
+
+func types_of(t *testing.T, src string) []TokenType {
+	t.Helper()
+	types := []TokenType{}
+	for _, tok := range tokens_of(t, src) {
+		types = append(types, tok.TokenType)
+	}
+	return types
+}
+
+func TestEveryOperator(t *testing.T) {
+	for _, op := range operator_tokens {
+		toks := tokens_of(t, op.text)
+		if len(toks) != 1 || toks[0].TokenType != op.TokenType || toks[0].text != op.text {
+			t.Errorf("%q: got %v, want one %v", op.text, toks, op.TokenType)
+		}
+		//and the same with something either side, so it isn't just the end of the line stopping it
+		got := types_of(t, "a "+op.text+" b")
+		want := []TokenType{Name_TType, op.TokenType, Name_TType}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q between names: got %v, want %v", op.text, got, want)
+		}
+	}
+}
+
+func TestMaximalMunch(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []TokenType
+	}{
+		{"...", []TokenType{Ellipsis}},
+		{"..", []TokenType{DotDot}},
+		{".", []TokenType{Dot}},
+		{"....", []TokenType{Ellipsis, Dot}},
+		{".....", []TokenType{Ellipsis, DotDot}},
+		{"a..b", []TokenType{Name_TType, DotDot, Name_TType}},
+		{"0..5", []TokenType{IntLiteral_TType, DotDot, IntLiteral_TType}},
+		{"a.b", []TokenType{Name_TType, Dot, Name_TType}},
+		{"->", []TokenType{Arrow}},
+		{"- >", []TokenType{Minus, CloseAlligator}},
+		{"-->", []TokenType{MinusMinus, CloseAlligator}},
+		{"->>", []TokenType{Arrow, CloseAlligator}},
+		{"+=", []TokenType{PlusAssign}},
+		{"+ =", []TokenType{Plus, Assignment}},
+		{"++", []TokenType{PlusPlus}},
+		{"+++", []TokenType{PlusPlus, Plus}},
+		{"++=", []TokenType{PlusPlus, Assignment}},
+		{"a++", []TokenType{Name_TType, PlusPlus}},
+		{"a+=1", []TokenType{Name_TType, PlusAssign, IntLiteral_TType}},
+		{"===", []TokenType{Equality, Assignment}},
+		{"<==", []TokenType{LessEqual, Assignment}},
+		{"< =", []TokenType{OpenAlligator, Assignment}},
+		{"!==", []TokenType{NotEqual, Assignment}},
+		{"||||", []TokenType{Or, Or}},
+	}
+	for _, test := range tests {
+		if got := types_of(t, test.src); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %v, want %v", test.src, got, test.want)
+		}
+	}
+}
+
+// ! used to come out as Unknown_TType, && left its second & behind and ] was a CloseParen
+func TestOperatorRegressions(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []TokenType
+	}{
+		{"!", []TokenType{Not}},
+		{"!a", []TokenType{Not, Name_TType}},
+		{"!!a", []TokenType{Not, Not, Name_TType}},
+		{"!(a)", []TokenType{Not, OpenParen, Name_TType, CloseParen}},
+		{"a != b", []TokenType{Name_TType, NotEqual, Name_TType}},
+		{"&&", []TokenType{And}},
+		{"a && b", []TokenType{Name_TType, And, Name_TType}},
+		{"a&&b", []TokenType{Name_TType, And, Name_TType}},
+		{"&&&", []TokenType{And, Reference}},
+		{"&a", []TokenType{Reference, Name_TType}},
+		{"]", []TokenType{CloseSquare}},
+		{"a[1]", []TokenType{Name_TType, OpenSquare, IntLiteral_TType, CloseSquare}},
+		{"[()]", []TokenType{OpenSquare, OpenParen, CloseParen, CloseSquare}},
+	}
+	for _, test := range tests {
+		if got := types_of(t, test.src); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %v, want %v", test.src, got, test.want)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	for _, src := range []string{"x", "_x", "_", "__add__", "x_1", "é"} {
+		toks := tokens_of(t, src)
+		if len(toks) != 1 || toks[0].TokenType != Name_TType || toks[0].text != src {
+			t.Errorf("%q: got %v, want one name", src, toks)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		src   string
+		want  TokenType
+		value uint64
+	}{
+		{"0", IntLiteral_TType, 0},
+		{"1_000", IntLiteral_TType, 1000},
+		{"0x1f", IntLiteral_TType, 0x1f},
+		{"0XFF", IntLiteral_TType, 0xff},
+		{"0o17", IntLiteral_TType, 0o17},
+		{"0b101", IntLiteral_TType, 5},
+		//only the first prefix counts, 0b after 0x is hex digits
+		{"0x0b1", IntLiteral_TType, 0x0b1},
+		{"0x0B", IntLiteral_TType, 0x0b},
+		{"1.5", FloatLiteral_TType, 0},
+		{".5", FloatLiteral_TType, 0},
+		{"1e3", FloatLiteral_TType, 0},
+	}
+	for _, test := range tests {
+		toks := tokens_of(t, test.src)
+		if len(toks) != 1 || toks[0].TokenType != test.want || toks[0].text != test.src {
+			t.Errorf("%q: got %v, want one %v", test.src, toks, test.want)
+			continue
+		}
+		if test.want != IntLiteral_TType {
+			continue
+		}
+		if value, err := int_literal_value(test.src); err != nil || value != test.value {
+			t.Errorf("%q: value %d, %v, want %d", test.src, value, err, test.value)
+		}
+	}
+	for _, bad := range []string{"0b0x1", "0x", "012", "12abc", "1__0"} {
+		errs := &ErrorCollector{}
+		Tokenize(bad, "test", errs)
+		if len(errs.errs) == 0 {
+			t.Errorf("%q: no error", bad)
+		}
+	}
+}
+
+func TestKeywords(t *testing.T) {
+	for word, want := range keywords {
+		toks := tokens_of(t, word)
+		if len(toks) != 1 || toks[0].TokenType != want {
+			t.Errorf("%q: got %v, want one %v", word, toks, want)
+		}
+		//a keyword is only a keyword on its own
+		for _, name := range []string{word + "s", "_" + word, word + "_1"} {
+			if got := types_of(t, name); !reflect.DeepEqual(got, []TokenType{Name_TType}) {
+				t.Errorf("%q: got %v, want a name", name, got)
+			}
+		}
+	}
+	//the reserved words are errors as names, not just parse errors further on
+	for _, src := range []string{"var if int", "for solve in s {\n}", "func print() {\n}"} {
+		if got := compile_codes(src); len(got) == 0 || got[0] != "E0106" {
+			t.Errorf("%q: got %v, want E0106 first", src, got)
+		}
+	}
+}
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`""`, ""},
+		{`"wow"`, "wow"},
+		{`"say \"hi\"\n"`, "say \"hi\"\n"},
+		{`"\t\r\0\\"`, "\t\r\x00\\"},
+		{`"\x41\x7e"`, "A~"},
+		{`"\u{1F600}\u{e9}"`, "😀é"},
+		{`"// not a comment"`, "// not a comment"},
+		{"`C:\\no\\escapes`", `C:\no\escapes`},
+		{"`say \"hi\"`", `say "hi"`},
+	}
+	for _, test := range tests {
+		toks := tokens_of(t, test.src)
+		if len(toks) != 1 || toks[0].TokenType != StringLiteral_TType || toks[0].text != test.want {
+			t.Errorf("%s: got %v, want %q", test.src, toks, test.want)
+		}
+	}
+	//a raw string can go over many lines, it is all one token on the line it started on
+	errs := &ErrorCollector{}
+	lines := Tokenize("print `one\ntwo`\nprint 3", "test", errs)
+	if len(errs.errs) > 0 || len(lines) != 2 {
+		t.Fatalf("got %v, %v", lines, errs.errs)
+	}
+	if raw := lines[0][1]; raw.text != "one\ntwo" || raw.line != 1 || raw.end_line() != 2 {
+		t.Errorf("got %q on lines %d to %d", raw.text, raw.line, raw.end_line())
+	}
+	if lines[1][0].line != 3 {
+		t.Errorf("the line after the raw string is line %d", lines[1][0].line)
+	}
+}
+
+// the codes of what tokenizing src reported
+func token_error_codes(src string) []string {
+	errs := &ErrorCollector{}
+	Tokenize(src, "test", errs)
+	codes := []string{}
+	for _, d := range errs.Diagnostics(nil) {
+		codes = append(codes, d.Code)
+	}
+	return codes
+}
+
+// the tokenizer reports to the ErrorCollector and keeps going, one bad character doesn't hide the next
+func TestTokenizerErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"a # b", []string{"E0001"}},
+		{"a | b", []string{"E0001"}},
+		{"a \xff b", []string{"E0001"}},
+		{`"no end`, []string{"E0002"}},
+		{"`no end\nat all", []string{"E0002"}},
+		{"/* no end\nat all", []string{"E0002"}},
+		{"a */ b", []string{"E0003"}},
+		{`"\q"`, []string{"E0004"}},
+		{`"\x4"`, []string{"E0004"}},
+		{`"\xff"`, []string{"E0004"}},
+		{`"\u41"`, []string{"E0004"}},
+		{`"\u{110000}"`, []string{"E0004"}},
+		{"012", []string{"E0005"}},
+		{"a # b\nc # d", []string{"E0001", "E0001"}},
+		{"a # b\n\"no end", []string{"E0001", "E0002"}},
+	}
+	for _, test := range tests {
+		if got := token_error_codes(test.src); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %v, want %v", test.src, got, test.want)
+		}
+	}
+	//where the error is, in characters
+	errs := &ErrorCollector{}
+	lines := Tokenize("print \"é\" # 1", "test", errs)
+	if d := errs.Diagnostics([]string{"print \"é\" # 1"}); len(d) != 1 || d[0].Line != 1 || d[0].Column != 11 {
+		t.Errorf("got %v", d)
+	}
+	if len(lines[0]) != 3 {
+		t.Errorf("the rest of the line is still tokenized: %v", lines[0])
+	}
+}
+
+func TestComments(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []TokenType
+	}{
+		{"a // b c", []TokenType{Name_TType, Comment_TType}},
+		{"a /* b */ c", []TokenType{Name_TType, Comment_TType, Name_TType}},
+		{"a /* b /* c */ d */ e", []TokenType{Name_TType, Comment_TType, Name_TType}},
+		{"/// docs", []TokenType{DocComment_TType}},
+		{"//// not docs", []TokenType{Comment_TType}},
+		{"a / b", []TokenType{Name_TType, Divide, Name_TType}},
+	}
+	for _, test := range tests {
+		if got := types_of(t, test.src); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %v, want %v", test.src, got, test.want)
+		}
+	}
+	//a block comment over many lines hides everything up to its end
+	errs := &ErrorCollector{}
+	lines := Tokenize("a /* b\nc /* d */\ne */ f", "test", errs)
+	got := [][]TokenType{}
+	for _, line := range lines {
+		types := []TokenType{}
+		for _, tok := range line {
+			types = append(types, tok.TokenType)
+		}
+		got = append(got, types)
+	}
+	want := [][]TokenType{{Name_TType, Comment_TType}, {Comment_TType}, {Comment_TType, Name_TType}}
+	if len(errs.errs) > 0 || !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, %v, want %v", got, errs.errs, want)
+	}
+}
@@ -0,0 +1,1313 @@
+package lang
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// how bad a diagnostic is, only errors stop a program from running
+type Severity int
+
+const (
+	ErrorSeverity Severity = iota
+	WarningSeverity
+	InfoSeverity
+)
+
+func (s Severity) String() string {
+	return []string{"error", "warning", "info"}[s]
+}
+
+type ErrorCollector struct {
+	errs       []error
+	shouldstop bool
+	num_errors int //how many of errs are errors, warnings and notes don't count toward max_errors
+}
+
+func (ec *ErrorCollector) SayErrors(lines []string) {
+	for _, err := range ec.errs {
+		fmt.Println(with_source(err, lines).Error())
+	}
+}
+
+// err with the line it points at filled in, so Error() can show it
+func with_source(err error, lines []string) error {
+	switch e := err.(type) {
+	case LocatedError:
+		e.line_src = source_line(lines, e.line)
+		return e
+	case RuntimeError:
+		e.line_src = source_line(lines, e.line)
+		return e
+	}
+	return err
+}
+
+// true if anything collected is worse than a warning
+func (ec *ErrorCollector) HasErrors() bool {
+	return ec.num_errors > 0
+}
+
+func is_error(err error) bool {
+	le, is_located := err.(LocatedError)
+	return !is_located || le.severity == ErrorSeverity
+}
+
+// for when there is no point going on, like the source not being readable or too many errors
+func (ec *ErrorCollector) ShouldStop() {
+	ec.shouldstop = true
+}
+
+// past this many errors the rest are most likely caused by the first ones
+const max_errors = 25
+
+func (ec *ErrorCollector) AddError(err error) {
+	if ec.shouldstop {
+		return
+	}
+	ec.errs = append(ec.errs, err)
+	if !is_error(err) {
+		return
+	}
+	ec.num_errors++
+	if ec.num_errors >= max_errors {
+		ec.errs = append(ec.errs, CodedError{"E0901", fmt.Errorf("too many errors, stopping")})
+		ec.ShouldStop()
+	}
+}
+
+// the text of line number line, "" if there is no such line
+func source_line(lines []string, line int) string {
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return lines[line-1]
+}
+
+// code is the stable code of the error, see explanations.md
+func NewLocatedError(code string, line, index int, msg string) LocatedError {
+	return LocatedError{
+		line:  line,
+		index: index,
+		msg:   msg,
+		code:  code,
+	}
+}
+
+type LocatedError struct {
+	line  int
+	index int
+	end   int //where what the error is about ends, 0 if only index is known
+	msg   string
+	file  string //"" if not known
+	code  string //like E0201, `lang explain E0201` says more about it
+
+	severity Severity
+
+	suggestion string //what was probably meant, "" if nothing
+	fix        *FixIt //how to change the source to what was probably meant, nil if there isn't an obvious fix
+
+	line_src string
+}
+
+func (le LocatedError) Error() string {
+	if le.suggestion != "" {
+		le.msg += fmt.Sprintf(", did you mean `%s`?", le.suggestion)
+	}
+	if le.code != "" {
+		le.msg = fmt.Sprintf("%s[%s]: %s", le.severity, le.code, le.msg)
+	} else if le.severity != ErrorSeverity {
+		le.msg = fmt.Sprintf("%s: %s", le.severity, le.msg)
+	}
+	s := ""
+	if le.line_src != "" {
+		if le.file != "" {
+			s += fmt.Sprintf("%s:%d:%d\n", le.file, le.line, le.Column())
+		}
+		s += le.line_src + "\n"
+		s += caret_padding(le.line_src, le.index) + "^\n"
+		s += fmt.Sprintf("%s", le.msg)
+
+	} else if le.file != "" {
+		s += fmt.Sprintf("%s:%d:%d : %s", le.file, le.line, le.Column(), le.msg)
+	} else {
+		s += fmt.Sprintf("line %d:%d : %s", le.line, le.Column(), le.msg)
+	}
+	return s
+}
+
+// the 1 based character column of the error like editors count it, index is in bytes so they differ
+// once there is unicode before it
+func (le LocatedError) Column() int {
+	return column_of(le.line_src, le.index)
+}
+
+// where in the source a node came from, start and end are byte indexes on line for slicing it,
+// column_start and column_end are the same in characters for showing to people
+type Span struct {
+	file                     string
+	line                     int
+	start, end               int
+	column_start, column_end int
+}
+
+// file:line:first-last with 1 based columns, like errors have
+func (s Span) String() string {
+	return fmt.Sprintf("%s:%d:%d-%d", s.file, s.line, s.column_start+1, s.column_end)
+}
+
+// an error pointing at the start of the span
+func (s Span) Located(code string, msg string) LocatedError {
+	le := NewLocatedError(code, s.line, s.start, msg)
+	le.file = s.file
+	le.end = s.end
+	return le
+}
+
+// a span covering both a and b, if they are on different lines it only covers a
+func (a Span) To(b Span) Span {
+	if a.line != b.line || b.end < a.start {
+		return a
+	}
+	return Span{file: a.file, line: a.line, start: a.start, end: b.end, column_start: a.column_start, column_end: b.column_end}
+}
+
+// spaces to put before a ^ so it lines up under index, keeping tabs so it still lines up when they are wide
+func caret_padding(line_src string, index int) string {
+	if index > len(line_src) {
+		return strings.Repeat(" ", index)
+	}
+	pad := ""
+	for _, c := range line_src[:index] {
+		if c == '\t' {
+			pad += "\t"
+		} else {
+			pad += " "
+		}
+	}
+	return pad
+}
+
+// allows parsers to tell the system that there may be a problem here in the future
+// example var x structA could be true or false depending on whether or not structA is defined in the future
+type ParseChecker struct {
+	*ErrorCollector
+	num_defined_types int
+
+	type_nums            map[string]int
+	types_defined        map[string]bool
+	var_types            map[string]ValueType
+	functions            map[string]*FunctionDefinition
+	called_functions     []function_call     //calls to check once every function has been seen
+	function             *FunctionDefinition //the function being parsed, nil at the top level
+	returns              []function_return   //returns to check once every call knows what it returns
+	assignments          []*SetNode          //the same for what variables are set to
+	host                 *Host               //go functions the program can call, nil if there are none
+	trace                io.Writer           //what the parser is doing, for debugging it, nil if no one is listening
+	block_depth          int
+	blocks_opened        int //how many { TreeifyBlock has taken, to tell if a statement got to its block
+	declared_type_checks map[string][]TypeDefinedCheck
+
+	file string //name of the file being parsed, for spans
+}
+
+// only the first error on a line is kept, anything after it on that line is usually caused by it
+func (pc *ParseChecker) AddError(err error) {
+	if le, is_located := err.(LocatedError); is_located {
+		for _, e := range pc.errs {
+			if other, other_located := e.(LocatedError); other_located && other.line == le.line {
+				return
+			}
+		}
+		if le.file == "" {
+			le.file = pc.file
+		}
+		err = le
+	}
+	pc.ErrorCollector.AddError(err)
+}
+
+func (pc *ParseChecker) tracef(format string, args ...any) {
+	if pc.trace != nil {
+		fmt.Fprintf(pc.trace, format, args...)
+	}
+}
+
+func (pc *ParseChecker) SpanOf(t Token) Span {
+	return Span{file: pc.file, line: t.line, start: t.index_start, end: t.index_end, column_start: t.column_start, column_end: t.column_end}
+}
+
+// from the start of first to the end of last
+func (pc *ParseChecker) SpanBetween(first, last Token) Span {
+	return pc.SpanOf(first).To(pc.SpanOf(last))
+}
+
+func (pc *ParseChecker) GetTypeNum(type_name string) int {
+	if num, exists := pc.type_nums[type_name]; exists {
+		//seen before, already have a number for it
+		return num
+	} else {
+		//never before seen, need to create a number for it
+		return pc.AddType(type_name)
+	}
+}
+func (pc *ParseChecker) AddType(name string) int {
+	pc.num_defined_types++
+	type_num := pc.num_defined_types + int(LastBuiltinType)
+	pc.type_nums[name] = type_num
+	return type_num
+}
+
+/*
+func (pc *ParseChecker) DefineType(type_name string, at_line int) {
+	if _, type_exists := pc.type_nums[type_name]; type_exists {
+		//redifinition of type type_name
+		pc.AddError(NewLocatedError("E0204", at_line, 0, "type redeclaration"))
+	} else {
+		// add to known types
+		pc.num_defined_types++
+		type_num := pc.num_defined_types + int(LastBuiltinType)
+		pc.type_nums[type_name] = type_num
+		//remove all the type listeners
+		delete(pc.declared_type_checks, type_name)
+	}
+}
+*/
+// add a watcher that will throw an error if the type is not defined by the end of analysis
+func (pc *ParseChecker) EnsureTypeDefined(tdc TypeDefinedCheck) {
+	//if type already defined, dont add watcher
+	type_name := tdc.type_name
+	if already_defined := pc.types_defined[type_name]; already_defined {
+		pc.tracef("type %s already defined\n", type_name)
+		return
+	}
+	// not yet defined, add watcher - 2 options if its already in the map
+	others, already_in := pc.declared_type_checks[type_name]
+	if already_in {
+		pc.tracef("type %s already has a watcher\n", type_name)
+
+		others = append(others, tdc)
+		pc.declared_type_checks[type_name] = others
+	} else {
+		pc.tracef("type %s getting set\n", type_name)
+
+		pc.declared_type_checks[type_name] = []TypeDefinedCheck{tdc}
+	}
+
+}
+
+// every type a declaration could name, builtin or defined
+func (pc *ParseChecker) TypeNames() []string {
+	names := []string{"int", "float", "string", "bool", "tuple", "universe"}
+	for name, defined := range pc.types_defined {
+		if defined {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// every type that was used but never defined becomes an error, in order of type name
+func (pc *ParseChecker) CheckTypesDefined() {
+	undefined_types_keys := make([]string, 0, len(pc.declared_type_checks))
+	for k := range pc.declared_type_checks {
+		undefined_types_keys = append(undefined_types_keys, k)
+	}
+	sort.Strings(undefined_types_keys)
+
+	for _, key := range undefined_types_keys {
+		for _, e := range pc.declared_type_checks[key] {
+			pc.AddError(e.error_if_not)
+		}
+	}
+}
+
+// a call and the token naming what it calls, for suggestions
+type function_call struct {
+	name_tok Token
+	call     *CallNode
+}
+
+func (pc *ParseChecker) CheckCall(fc function_call) {
+	params := -1 //any number
+	if fd, defined := pc.functions[fc.call.name]; defined {
+		params = len(fd.parameterNames)
+		fc.call.ret_type = fd.returnType
+	} else if hf := pc.host.lookup(fc.call.name); hf != nil {
+		fc.call.ret_type = hf.returnType
+		if hf.params != nil {
+			params = len(hf.params)
+		}
+	} else {
+		pc.AddError(NewLocatedError("E0202", fc.name_tok.line, fc.name_tok.index_start, fmt.Sprintf("undefined function %s", fc.call.name)).Suggest(fc.name_tok, append(names_of(pc.functions), pc.host.Names()...)))
+		return
+	}
+	if params >= 0 && params != len(fc.call.args) {
+		pc.AddError(fc.call.span.Located("E0206", fmt.Sprintf("%s takes %d arguments but was given %d", fc.call.name, params, len(fc.call.args))))
+	}
+}
+
+// the arguments of a call to a go function have to be what it takes, unless their type isn't known yet
+func (pc *ParseChecker) CheckArguments(fc function_call) {
+	if _, defined := pc.functions[fc.call.name]; defined {
+		return
+	}
+	hf := pc.host.lookup(fc.call.name)
+	if hf == nil || hf.params == nil || len(hf.params) != len(fc.call.args) {
+		return
+	}
+	for i, arg := range fc.call.args {
+		if given := arg.ReturnsType(nil); !host_accepts(hf.params[i], given) {
+			pc.AddError(arg.Span().Located("E0205", fmt.Sprintf("%s takes %v as argument %d, not %v", fc.call.name, hf.params[i], i+1, given)))
+		}
+	}
+}
+
+// true if a go function taking param could be given a value of type given
+func host_accepts(param ValueType, given ValueType) bool {
+	switch {
+	case param == NoType, given == NoType, param == given:
+		return true
+	case param == Tuple && given == OneSolution:
+		//a solution turns into a struct like a tuple does
+		return true
+	}
+	return false
+}
+
+// a return and the function it leaves
+type function_return struct {
+	function *FunctionDefinition
+	node     *ReturnNode
+}
+
+// what is returned has to be what the function says it returns, anything whose type isn't known
+// when parsing is left to be what it is
+func (pc *ParseChecker) CheckReturn(fr function_return) {
+	fd, rn := fr.function, fr.node
+	if rn.value == nil {
+		if fd.returnType != NoType {
+			pc.AddError(rn.span.Located("E0205", fmt.Sprintf("%s returns %v but this returns nothing", fd.name, fd.returnType)))
+		}
+		return
+	}
+	if fd.returnType == NoType {
+		pc.AddError(rn.value.Span().Located("E0205", fmt.Sprintf("%s doesn't return anything but this returns a value", fd.name)))
+		return
+	}
+	if returned := rn.value.ReturnsType(nil); returned != NoType && returned != fd.returnType {
+		pc.AddError(rn.value.Span().Located("E0205", fmt.Sprintf("%s returns %v, not %v", fd.name, fd.returnType, returned)))
+	}
+}
+
+// a variable can only be set to a value of the type it was declared with
+// types the program defines are left alone, what is wrong with them is said by CheckTypesDefined
+func (pc *ParseChecker) CheckAssignment(sn *SetNode) {
+	if sn.my_type == NoType || sn.my_type >= LastBuiltinType {
+		return
+	}
+	if given := sn.from.ReturnsType(nil); given != NoType && given != sn.my_type {
+		pc.AddError(sn.from.Span().Located("E0205", fmt.Sprintf("%s is %v, it can't be set to %v", sn.to, sn.my_type, given)))
+	}
+}
+
+type TypeDefinedCheck struct {
+	type_name    string
+	error_if_not LocatedError
+}
+
+// errs is shared with Tokenize so everything wrong with the source gets said together
+// anything wrong goes into errs, nothing is printed
+func MakeTree(token_lines [][]Token, file string, errs *ErrorCollector) ([]ASTNode, bool) {
+	return make_tree(&TokenLines{lines: token_lines}, errs, CompileOptions{File: file})
+}
+
+// parses straight from a lexer, so the tokens are only made as the parser gets to them
+func MakeTreeFromLexer(lx *Lexer) ([]ASTNode, bool) {
+	return make_tree(lx, lx.errs, CompileOptions{File: lx.file})
+}
+
+// only the File, Host and Trace of opts matter here
+func make_tree(source LineSource, errs *ErrorCollector, opts CompileOptions) ([]ASTNode, bool) {
+	pc := &ParseChecker{
+		file:                 opts.File,
+		ErrorCollector:       errs,
+		num_defined_types:    0,
+		type_nums:            map[string]int{},
+		declared_type_checks: map[string][]TypeDefinedCheck{},
+		var_types:            opts.Host.global_types(),
+		functions:            map[string]*FunctionDefinition{},
+		host:                 opts.Host,
+		trace:                opts.Trace,
+	}
+	lg := &LineGiver{source: source}
+	ast_head := TreeifyStatements(lg, pc, false)
+	//functions can be called before they are defined, so this can only be checked at the end
+	for _, fc := range pc.called_functions {
+		pc.CheckCall(fc)
+	}
+	//a call can be given what another call returns, so this waits until they all know that
+	for _, fc := range pc.called_functions {
+		pc.CheckArguments(fc)
+	}
+	for _, fr := range pc.returns {
+		pc.CheckReturn(fr)
+	}
+	for _, sn := range pc.assignments {
+		pc.CheckAssignment(sn)
+	}
+
+	for i := range ast_head {
+		pc.tracef("%+v\n", ast_head[i])
+	}
+	pc.CheckTypesDefined()
+	return ast_head, !pc.HasErrors()
+}
+
+// reads statements until the end of the file, or until a line starting with } if in_block
+func TreeifyStatements(lg *LineGiver, pc *ParseChecker, in_block bool) []ASTNode {
+	nodes := []ASTNode{}
+	for !pc.shouldstop {
+		tg := lg.NextLine()
+		if tg == nil {
+			if in_block {
+				last := lg.LastToken()
+				pc.AddError(NewLocatedError("E0103", last.line, last.index_end, "expected `}` before the end of the file"))
+			}
+			return nodes
+		}
+		if in_block && tg.PeekNext().TokenType == CloseCurly {
+			return nodes
+		}
+		errors_before := len(pc.errs)
+		blocks_before := pc.blocks_opened
+		nodes = append(nodes, TreeifyStatement(lg, pc)...)
+		if len(pc.errs) > errors_before && pc.blocks_opened == blocks_before {
+			//it gave up before getting to its block, skip the block so its insides aren't taken as statements out here
+			skip_block(lg, tg)
+		}
+		if lg.current.HasNext() {
+			extra := lg.current.PeekNext()
+			pc.AddError(NewLocatedError("E0101", extra.line, extra.index_start, fmt.Sprintf("unexpected %v after statement", extra.TokenType)))
+		}
+	}
+	return nodes
+}
+
+// skips lines until every { opened on header is closed, leaving lg on the line with the last }
+func skip_block(lg *LineGiver, header *TokenGiver) {
+	depth := 0
+	tg := header
+	for {
+		for _, t := range tg.toks {
+			if t.TokenType == OpenCurly {
+				depth++
+			} else if t.TokenType == CloseCurly {
+				depth--
+			}
+		}
+		tg.index = len(tg.toks)
+		if depth <= 0 {
+			return
+		}
+		tg = lg.NextLine()
+		if tg == nil {
+			return
+		}
+	}
+}
+
+// parses the statement starting at the current line, statements with blocks leave lg on the line with their closing }
+func TreeifyStatement(lg *LineGiver, pc *ParseChecker) []ASTNode {
+	tg := lg.current
+	tok := tg.PeekNext()
+	switch tok.TokenType {
+	case Var_TType:
+		doc := lg.doc
+		nodes := TreeifyVarStatement(tg, pc)
+		if len(nodes) > 0 {
+			if declaration, is_declaration := nodes[0].(*DeclareNode); is_declaration {
+				declaration.doc = doc
+			}
+		}
+		return nodes
+	case Print_TType:
+		print_tok := tg.ConsumeNext()
+		argument := TreeifyExpression(tg, pc)
+		return []ASTNode{&PrintStatement{argument: argument, span: pc.SpanBetween(print_tok, tg.LastToken())}}
+	case Require_TType:
+		return []ASTNode{TreeifyRequireStatement(tg, pc)}
+	case Solve_TType:
+		return []ASTNode{TreeifySolveStatement(lg, pc)}
+	case Option_TType:
+		return []ASTNode{TreeifyOptionStatement(lg, pc)}
+	case For_TType:
+		return []ASTNode{TreeifyForStatement(lg, pc)}
+	case Func_TType:
+		return []ASTNode{TreeifyFunctionDefinition(lg, pc)}
+	case Return_TType:
+		return_tok := tg.ConsumeNext()
+		rn := &ReturnNode{span: pc.SpanOf(return_tok)}
+		if !tg.AtStatementEnd() {
+			rn.value = TreeifyExpression(tg, pc)
+			rn.span = pc.SpanBetween(return_tok, tg.LastToken())
+		}
+		if pc.function == nil {
+			pc.AddError(NewLocatedError("E0109", return_tok.line, return_tok.index_start, "return outside of a function"))
+		} else {
+			pc.returns = append(pc.returns, function_return{function: pc.function, node: rn})
+		}
+		return []ASTNode{rn}
+	case Name_TType:
+		if len(tg.toks) > tg.index+1 && tg.toks[tg.index+1].TokenType == Assignment {
+			return []ASTNode{TreeifyAssignment(tg, pc)}
+		}
+		if len(tg.toks) > tg.index+1 && tg.toks[tg.index+1].TokenType == OpenParen {
+			//a call on its own, whatever it returns is thrown away
+			return []ASTNode{TreeifyExpression(tg, pc)}
+		}
+	}
+	if tok.TokenType == CloseCurly {
+		pc.AddError(NewLocatedError("E0104", tok.line, tok.index_start, "`}` without a `{` to close"))
+	} else if tok.TokenType == Name_TType {
+		//most likely a misspelled keyword
+		pc.AddError(NewLocatedError("E0101", tok.line, tok.index_start, fmt.Sprintf("unexpected name %s at the start of a statement", tok.text)).Suggest(tok, names_of(keywords)))
+	} else {
+		pc.AddError(NewLocatedError("E0101", tok.line, tok.index_start, fmt.Sprintf("unexpected %v at the start of a statement", tok.TokenType)))
+	}
+	tg.index = len(tg.toks)
+	return []ASTNode{}
+}
+
+// { lines } either spread over lines or all on the current one
+func TreeifyBlock(lg *LineGiver, pc *ParseChecker) *BlockNode {
+	tg := lg.current
+	if !tg.HasNext() || tg.PeekNext().TokenType != OpenCurly {
+		last := lg.LastToken()
+		pc.AddError(NewLocatedError("E0102", last.line, last.index_end, "expected `{`"))
+		return &BlockNode{span: pc.SpanOf(last)}
+	}
+	open_tok := tg.ConsumeNext()
+	pc.blocks_opened++
+	pc.block_depth++
+	defer func() { pc.block_depth-- }()
+	if !tg.HasNext() {
+		block := &BlockNode{lines: TreeifyStatements(lg, pc, true), span: pc.SpanOf(open_tok)}
+		if lg.current.HasNext() && lg.current.PeekNext().TokenType == CloseCurly {
+			lg.current.ConsumeNext()
+		}
+		return block
+	}
+	//single line block
+	block := &BlockNode{lines: []ASTNode{}, span: pc.SpanOf(open_tok)}
+	if tg.PeekNext().TokenType != CloseCurly {
+		block.lines = TreeifyStatement(lg, pc)
+	}
+	if !lg.current.HasNext() || lg.current.PeekNext().TokenType != CloseCurly {
+		last := lg.LastToken()
+		pc.AddError(NewLocatedError("E0102", last.line, last.index_end, "expected `}`"))
+		return block
+	}
+	block.span = pc.SpanBetween(open_tok, lg.current.ConsumeNext())
+	return block
+}
+
+func TreeifyAssignment(tg *TokenGiver, pc *ParseChecker) ASTNode {
+	name_tok := tg.ConsumeNext()
+	tg.ConsumeNext() // =
+	var_type, declared := pc.var_types[name_tok.text]
+	if !declared {
+		pc.AddError(NewLocatedError("E0201", name_tok.line, name_tok.index_start, fmt.Sprintf("assignment to undeclared variable %s", name_tok.text)).Suggest(name_tok, names_of(pc.var_types)))
+	}
+	from := TreeifyExpression(tg, pc)
+	sn := &SetNode{
+		to:      name_tok.text,
+		my_type: var_type,
+		from:    from,
+		span:    pc.SpanBetween(name_tok, tg.LastToken()),
+	}
+	pc.assignments = append(pc.assignments, sn)
+	return sn
+}
+
+// returns true, intenal if it is vec<internal>, else false ""
+func is_vector_wrapper(s string) (bool, string) {
+	prefix := "vector<"
+	if s[0:len(prefix)] == prefix && s[len(s)-2:] == ">" {
+		internal := s[len(prefix) : len(s)-1]
+		return true, internal
+	}
+	return false, ""
+}
+
+func TreeifyVarStatement(tg *TokenGiver, pc *ParseChecker) []ASTNode {
+	nodes := []ASTNode{}
+	var_tok := tg.ConsumeNext() // should just be var
+	if !tg.HasNext() {
+		pc.AddError(NewLocatedError("E0105", var_tok.line, var_tok.index_end, "expected variable name"))
+		return nodes
+	}
+	name_tok := tg.ConsumeNext()
+	if reserved_name(name_tok, "variable", pc) {
+		tg.index = len(tg.toks)
+		return nodes
+	}
+	if name_tok.TokenType != Name_TType {
+		pc.AddError(NewLocatedError("E0105", name_tok.line, name_tok.index_start, "expected a variable name after `var`"))
+		tg.index = len(tg.toks)
+		return nodes
+	}
+	if !tg.HasNext() || (tg.PeekNext().TokenType != BuiltinType_TType && tg.PeekNext().TokenType != Name_TType) {
+		pc.AddError(NewLocatedError("E0105", var_tok.line, name_tok.index_end, "expected variable type"))
+		return nodes
+	}
+	var_type_tok := tg.ConsumeNext()
+	var actual_type ValueType
+	var is_primitive = true
+	var is_simple = true //not a vec or tuple
+	if var_type_tok.TokenType == BuiltinType_TType {
+		switch var_type_tok.text {
+		case "bool":
+			actual_type = Bool
+		case "int":
+			actual_type = Int
+		case "float":
+			actual_type = Float
+		case "string":
+			actual_type = String
+		case "universe":
+			actual_type = Universe
+			is_primitive = false
+		case "tuple":
+			actual_type = Tuple
+		default:
+			//filter out complex types
+			if is_vec, sub_type := is_vector_wrapper(var_type_tok.text); is_vec {
+				actual_type = Vector
+				is_primitive = false
+				pc.tracef("%s\n", sub_type)
+				panic("unimplemented")
+			} else {
+				pc.AddError(NewLocatedError("E0203", var_tok.line, var_type_tok.index_start, "unknown builtin type, this should probably never happen if this analysis is well written"))
+				actual_type = NoType
+			}
+		}
+	}
+	if var_type_tok.TokenType == Name_TType { //user defined type
+		is_primitive = false
+		is_simple = true
+		type_name := var_type_tok.text
+
+		type_num := pc.GetTypeNum(type_name)
+		//add watcher to make sure this type actually gets defined later
+		pc.EnsureTypeDefined(TypeDefinedCheck{
+			type_name:    var_type_tok.text,
+			error_if_not: NewLocatedError("E0203", var_tok.line, var_type_tok.index_start, fmt.Sprintf("type %s was never defined", type_name)).Suggest(var_type_tok, pc.TypeNames()),
+		})
+		actual_type = ValueType(type_num)
+	}
+	if g, is_host := pc.host.lookup_global(name_tok.text); is_host {
+		pc.AddError(NewLocatedError("E0204", name_tok.line, name_tok.index_start, fmt.Sprintf("%s is already a global given by the host, it is %v", name_tok.text, g.value_type)))
+	}
+	pc.var_types[name_tok.text] = actual_type
+	if is_primitive {
+		declaration := &DeclareNode{
+			name:    name_tok.text,
+			my_type: actual_type,
+			span:    pc.SpanBetween(var_tok, var_type_tok),
+		}
+		if tg.HasNext() && tg.PeekNext().TokenType == In_TType {
+			//var x int in low..high, a variable a solve block can search for
+			in_tok := tg.ConsumeNext()
+			if actual_type != Int {
+				pc.AddError(NewLocatedError("E0205", in_tok.line, in_tok.index_start, fmt.Sprintf("only ints can have a range, %s is a %v", name_tok.text, actual_type)))
+			}
+			declaration.low = TreeifyExpression(tg, pc)
+			if !tg.HasNext() || tg.PeekNext().TokenType != DotDot {
+				pc.AddError(NewLocatedError("E0102", in_tok.line, tg.LastToken().index_end, "expected `..` between the ends of the range"))
+				return append(nodes, declaration)
+			}
+			tg.ConsumeNext()
+			declaration.high = TreeifyExpression(tg, pc)
+			declaration.span = pc.SpanBetween(var_tok, tg.LastToken())
+		}
+		nodes = append(nodes, declaration)
+	} else if is_simple { //not array type
+		nodes = append(nodes, &DeclareNode{
+			name:    name_tok.text,
+			my_type: actual_type,
+			span:    pc.SpanBetween(var_tok, var_type_tok),
+		})
+	} else {
+		panic("unimplemented")
+	}
+
+	if tg.AtStatementEnd() {
+		//we good, just a declaration, not a setting
+		return nodes
+	}
+	if tg.PeekNext().TokenType != Assignment {
+		pc.AddError(NewLocatedError("E0102", var_tok.line, tg.PeekNext().index_start, "expected `=` or newline"))
+		tg.index = len(tg.toks)
+		return nodes
+	}
+	tg.ConsumeNext() //take =
+
+	exp := TreeifyExpression(tg, pc)
+	if actual_type == Universe {
+		//universes are not evaluated when set, the solver evaluates them later
+		statements := []ASTNode{exp}
+		if tuple, is_tuple := exp.(*TupleLiteral); is_tuple {
+			statements = tuple.values
+		}
+		exp = &UniverseLiteral{statements: statements, span: exp.Span()}
+	}
+
+	sn := &SetNode{
+		to:      name_tok.text,
+		my_type: actual_type,
+		from:    exp,
+		span:    pc.SpanBetween(var_tok, tg.LastToken()),
+	}
+	pc.assignments = append(pc.assignments, sn)
+	nodes = append(nodes, sn)
+	//panic("unimplemented declaration and assignment in the same line")
+
+	return nodes
+}
+func TreeifyExpression(tg *TokenGiver, pc *ParseChecker) ASTNode {
+	return TreeifyBinary(tg, pc, 1)
+}
+
+// how tightly a binary operator binds, 0 if the token is not a binary operator
+func binary_precedence(t TokenType) int {
+	switch t {
+	case Or:
+		return 1
+	case And:
+		return 2
+	case Equality, NotEqual:
+		return 3
+	case OpenAlligator, CloseAlligator, LessEqual, GreaterEqual:
+		return 4
+	case Plus, Minus:
+		return 5
+	case Multiply, Divide, Modulo:
+		return 6
+	}
+	return 0
+}
+
+// parses operators that bind at least as tightly as min_precedence
+func TreeifyBinary(tg *TokenGiver, pc *ParseChecker, min_precedence int) ASTNode {
+	left := TreeifyUnary(tg, pc)
+	for tg.HasNext() {
+		op := tg.PeekNext()
+		precedence := binary_precedence(op.TokenType)
+		if precedence == 0 || precedence < min_precedence {
+			break
+		}
+		tg.ConsumeNext()
+		right := TreeifyBinary(tg, pc, precedence+1)
+		left = &BinaryOpNode{op: op.TokenType, left: left, right: right, span: left.Span().To(right.Span()), op_span: pc.SpanOf(op)}
+	}
+	return left
+}
+
+func TreeifyUnary(tg *TokenGiver, pc *ParseChecker) ASTNode {
+	if tg.HasNext() && tg.PeekNext().TokenType == Minus {
+		minus_tok := tg.ConsumeNext()
+		if tg.HasNext() && tg.PeekNext().TokenType == IntLiteral_TType {
+			//the smallest int has no positive version, so it can only be made here
+			if value, _ := int_literal_value(tg.PeekNext().text); value == -math.MinInt64 {
+				return &IntLiteral{value: math.MinInt64, span: pc.SpanOf(minus_tok).To(pc.SpanOf(tg.ConsumeNext()))}
+			}
+		}
+		operand := TreeifyUnary(tg, pc)
+		span := pc.SpanOf(minus_tok).To(operand.Span())
+		if il, is_literal := operand.(*IntLiteral); is_literal {
+			return &IntLiteral{value: -il.value, span: span}
+		}
+		if fl, is_literal := operand.(*FloatLiteral); is_literal {
+			return &FloatLiteral{value: -fl.value, span: span}
+		}
+		return &NegateNode{operand: operand, span: span, op_span: pc.SpanOf(minus_tok)}
+	}
+	if tg.HasNext() && tg.PeekNext().TokenType == Not {
+		not_tok := tg.ConsumeNext()
+		operand := TreeifyUnary(tg, pc)
+		return &BinaryOpNode{op: Not, left: &BoolLiteral{value: true, span: pc.SpanOf(not_tok)}, right: operand, span: pc.SpanOf(not_tok).To(operand.Span()), op_span: pc.SpanOf(not_tok)}
+	}
+	return TreeifyPostfix(tg, pc)
+}
+
+// a primary expression followed by any number of [index] and .name
+func TreeifyPostfix(tg *TokenGiver, pc *ParseChecker) ASTNode {
+	exp := TreeifyPrimary(tg, pc)
+	for tg.HasNext() && (tg.PeekNext().TokenType == OpenSquare || tg.PeekNext().TokenType == Dot) {
+		open_tok := tg.ConsumeNext()
+		if open_tok.TokenType == Dot {
+			if !tg.HasNext() || tg.PeekNext().TokenType != Name_TType {
+				last := tg.LastToken()
+				pc.AddError(NewLocatedError("E0105", last.line, last.index_end, "expected a name after `.`"))
+				return exp
+			}
+			name_tok := tg.ConsumeNext()
+			exp = &PropertyNode{target: exp, name: name_tok.text, span: exp.Span().To(pc.SpanOf(name_tok))}
+			continue
+		}
+		index := TreeifyExpression(tg, pc)
+		if !tg.HasNext() || tg.PeekNext().TokenType != CloseSquare {
+			last := tg.LastToken()
+			pc.AddError(NewLocatedError("E0102", last.line, last.index_end, "expected `]`"))
+			return exp
+		}
+		close_tok := tg.ConsumeNext()
+		exp = &IndexNode{target: exp, index: index, span: exp.Span().To(pc.SpanOf(close_tok))}
+	}
+	return exp
+}
+
+func TreeifyPrimary(tg *TokenGiver, pc *ParseChecker) ASTNode {
+	if !tg.HasNext() {
+		last := tg.LastToken()
+		pc.AddError(NewLocatedError("E0102", last.line, last.index_end, "expected an expression"))
+		return &IntLiteral{value: 0, span: pc.SpanOf(last)}
+	}
+	tok := tg.ConsumeNext()
+	switch tok.TokenType {
+	case IntLiteral_TType:
+		//the tokenizer already made sure it is a valid number
+		value, _ := int_literal_value(tok.text)
+		if value > math.MaxInt64 {
+			pc.AddError(NewLocatedError("E0006", tok.line, tok.index_start, fmt.Sprintf("integer literal %s is too big for an int, it only fits when negated", tok.text)))
+		}
+		return &IntLiteral{value: int(value), span: pc.SpanOf(tok)}
+	case FloatLiteral_TType:
+		value, _ := strconv.ParseFloat(tok.text, 64)
+		return &FloatLiteral{value: value, span: pc.SpanOf(tok)}
+	case True_TType, False_TType:
+		return &BoolLiteral{value: tok.TokenType == True_TType, span: pc.SpanOf(tok)}
+	case StringLiteral_TType:
+		return &StringLiteral{value: tok.text, span: pc.SpanOf(tok)}
+	case Name_TType:
+		if tg.HasNext() && tg.PeekNext().TokenType == OpenParen {
+			return TreeifyCall(tok, tg, pc)
+		}
+		var_type, declared := pc.var_types[tok.text]
+		if !declared {
+			//a misspelled true, false or keyword ends up here too
+			candidates := append(names_of(pc.var_types), names_of(keywords)...)
+			pc.AddError(NewLocatedError("E0201", tok.line, tok.index_start, fmt.Sprintf("undefined variable %s", tok.text)).Suggest(tok, candidates))
+		}
+		return &GetNode{name: tok.text, v_type: var_type, span: pc.SpanOf(tok)}
+	case OpenParen:
+		//(a) is just a, (a, b, c) is a tuple
+		values := []ASTNode{TreeifyExpression(tg, pc)}
+		for tg.HasNext() && tg.PeekNext().TokenType == Comma {
+			tg.ConsumeNext()
+			values = append(values, TreeifyExpression(tg, pc))
+		}
+		if !tg.HasNext() || tg.PeekNext().TokenType != CloseParen {
+			last := tg.LastToken()
+			pc.AddError(NewLocatedError("E0102", last.line, last.index_end, "expected `)`"))
+		} else {
+			tg.ConsumeNext()
+		}
+		if len(values) == 1 {
+			return values[0]
+		}
+		return &TupleLiteral{values: values, span: pc.SpanBetween(tok, tg.LastToken())}
+	}
+	pc.AddError(NewLocatedError("E0101", tok.line, tok.index_start, fmt.Sprintf("expected an expression, got %v", tok.TokenType)))
+	return &IntLiteral{value: 0, span: pc.SpanOf(tok)}
+}
+
+// require condition or require value expected_value
+func TreeifyRequireStatement(tg *TokenGiver, pc *ParseChecker) ASTNode {
+	require_tok := tg.ConsumeNext()
+	condition := TreeifyExpression(tg, pc)
+	if !tg.AtStatementEnd() {
+		expected := TreeifyExpression(tg, pc)
+		condition = &BinaryOpNode{op: Equality, left: condition, right: expected, span: condition.Span().To(expected.Span()), op_span: expected.Span()}
+	}
+	return &RequireNode{condition: condition, span: pc.SpanBetween(require_tok, tg.LastToken())}
+}
+
+// solve universe, results{ ... } or solve universe, results explain{ ... }
+func TreeifySolveStatement(lg *LineGiver, pc *ParseChecker) ASTNode {
+	tg := lg.current
+	solve_tok := tg.ConsumeNext()
+	if !tg.HasNext() || tg.PeekNext().TokenType != Name_TType {
+		pc.AddError(NewLocatedError("E0105", solve_tok.line, solve_tok.index_end, "expected the name of a universe to solve"))
+		tg.index = len(tg.toks)
+		return &BlockNode{span: pc.SpanOf(tg.LastToken())}
+	}
+	universe_tok := tg.ConsumeNext()
+	if universe_type, declared := pc.var_types[universe_tok.text]; !declared {
+		pc.AddError(NewLocatedError("E0201", universe_tok.line, universe_tok.index_start, fmt.Sprintf("undefined variable %s", universe_tok.text)).Suggest(universe_tok, names_of(pc.var_types)))
+	} else if universe_type != Universe {
+		pc.AddError(NewLocatedError("E0205", universe_tok.line, universe_tok.index_start, fmt.Sprintf("can only solve a universe, %s is a %v", universe_tok.text, universe_type)))
+	}
+	if !tg.HasNext() || tg.PeekNext().TokenType != Comma {
+		pc.AddError(NewLocatedError("E0102", universe_tok.line, universe_tok.index_end, "expected `,` and the name of the solution set"))
+		tg.index = len(tg.toks)
+		return &BlockNode{span: pc.SpanOf(tg.LastToken())}
+	}
+	tg.ConsumeNext()
+	if tg.HasNext() && reserved_name(tg.PeekNext(), "solution set", pc) {
+		tg.index = len(tg.toks)
+		return &BlockNode{span: pc.SpanOf(tg.LastToken())}
+	}
+	if !tg.HasNext() || tg.PeekNext().TokenType != Name_TType {
+		pc.AddError(NewLocatedError("E0105", universe_tok.line, tg.LastToken().index_end, "expected the name of the solution set"))
+		tg.index = len(tg.toks)
+		return &BlockNode{span: pc.SpanOf(tg.LastToken())}
+	}
+	results_tok := tg.ConsumeNext()
+	pc.var_types[results_tok.text] = SolutionSet
+	sn := &SolveNode{
+		universe: universe_tok.text,
+		results:  results_tok.text,
+	}
+	//limit, minimize, maximize and explain are only special here so they are not keywords
+	for tg.HasNext() && tg.PeekNext().TokenType == Name_TType {
+		clause_tok := tg.PeekNext()
+		switch clause_tok.text {
+		case "explain":
+			sn.explain = true
+		case "limit":
+			tg.ConsumeNext()
+			sn.limit = TreeifyExpression(tg, pc)
+			continue
+		case "minimize", "maximize":
+			if sn.objective != nil {
+				pc.AddError(NewLocatedError("E0108", clause_tok.line, clause_tok.index_start, "a solve block can only minimize or maximize one thing"))
+			}
+			tg.ConsumeNext()
+			sn.maximize = clause_tok.text == "maximize"
+			sn.objective = TreeifyExpression(tg, pc)
+			continue
+		default:
+			pc.AddError(NewLocatedError("E0108", clause_tok.line, clause_tok.index_start, fmt.Sprintf("unknown solve option %s, expected limit, minimize, maximize or explain", clause_tok.text)).Suggest(clause_tok, []string{"limit", "minimize", "maximize", "explain"}))
+		}
+		tg.ConsumeNext()
+	}
+	sn.span = pc.SpanBetween(solve_tok, tg.LastToken())
+	sn.body = TreeifyBlock(lg, pc)
+	return sn
+}
+
+// for name in solutions{ ... }
+func TreeifyForStatement(lg *LineGiver, pc *ParseChecker) ASTNode {
+	tg := lg.current
+	for_tok := tg.ConsumeNext()
+	if tg.HasNext() && reserved_name(tg.PeekNext(), "loop variable", pc) {
+		tg.index = len(tg.toks)
+		return &BlockNode{span: pc.SpanOf(tg.LastToken())}
+	}
+	if !tg.HasNext() || tg.PeekNext().TokenType != Name_TType {
+		pc.AddError(NewLocatedError("E0105", for_tok.line, for_tok.index_end, "expected the name of the loop variable"))
+		tg.index = len(tg.toks)
+		return &BlockNode{span: pc.SpanOf(tg.LastToken())}
+	}
+	name_tok := tg.ConsumeNext()
+	if !tg.HasNext() || tg.PeekNext().TokenType != In_TType {
+		pc.AddError(NewLocatedError("E0102", name_tok.line, name_tok.index_end, "expected `in`"))
+		tg.index = len(tg.toks)
+		return &BlockNode{span: pc.SpanOf(tg.LastToken())}
+	}
+	tg.ConsumeNext()
+	set := TreeifyExpression(tg, pc)
+	pc.var_types[name_tok.text] = OneSolution
+	span := pc.SpanBetween(for_tok, tg.LastToken())
+	return &ForInNode{
+		variable: name_tok.text,
+		set:      set,
+		body:     TreeifyBlock(lg, pc),
+		span:     span,
+	}
+}
+
+// name(a, b) or name(a b)
+func TreeifyCall(name_tok Token, tg *TokenGiver, pc *ParseChecker) ASTNode {
+	tg.ConsumeNext() // (
+	call := &CallNode{name: name_tok.text, args: []ASTNode{}, span: pc.SpanOf(name_tok)}
+	pc.called_functions = append(pc.called_functions, function_call{name_tok: name_tok, call: call})
+	for tg.HasNext() && tg.PeekNext().TokenType != CloseParen {
+		call.args = append(call.args, TreeifyExpression(tg, pc))
+		if tg.HasNext() && tg.PeekNext().TokenType == Comma {
+			tg.ConsumeNext()
+		}
+	}
+	if !tg.HasNext() {
+		last := tg.LastToken()
+		pc.AddError(NewLocatedError("E0102", last.line, last.index_end, "expected `)` at the end of the arguments"))
+		return call
+	}
+	call.span = pc.SpanBetween(name_tok, tg.ConsumeNext())
+	return call
+}
+
+// the type named by a token in a declaration
+func TreeifyType(type_tok Token, pc *ParseChecker) ValueType {
+	if type_tok.TokenType == Name_TType {
+		type_num := pc.GetTypeNum(type_tok.text)
+		pc.EnsureTypeDefined(TypeDefinedCheck{
+			type_name:    type_tok.text,
+			error_if_not: NewLocatedError("E0203", type_tok.line, type_tok.index_start, fmt.Sprintf("type %s was never defined", type_tok.text)).Suggest(type_tok, pc.TypeNames()),
+		})
+		return ValueType(type_num)
+	}
+	switch type_tok.text {
+	case "bool":
+		return Bool
+	case "int":
+		return Int
+	case "float":
+		return Float
+	case "string":
+		return String
+	case "universe":
+		return Universe
+	case "tuple":
+		return Tuple
+	}
+	pc.AddError(NewLocatedError("E0203", type_tok.line, type_tok.index_start, fmt.Sprintf("%s is not a type", type_tok.text)))
+	return NoType
+}
+
+// adds an error and returns true if tok is a keyword being used as the name of a what
+func reserved_name(tok Token, what string, pc *ParseChecker) bool {
+	if _, is_keyword := keywords[tok.text]; !is_keyword || tok.TokenType == StringLiteral_TType {
+		return false
+	}
+	pc.AddError(NewLocatedError("E0106", tok.line, tok.index_start, fmt.Sprintf("%s is a reserved word and can't be used as a %s name", tok.text, what)))
+	return true
+}
+
+func is_type_token(t Token) bool {
+	return t.TokenType == BuiltinType_TType || t.TokenType == Name_TType
+}
+
+// func name(a int, b int) int { ... }
+func TreeifyFunctionDefinition(lg *LineGiver, pc *ParseChecker) ASTNode {
+	tg := lg.current
+	func_tok := tg.ConsumeNext()
+	if pc.block_depth > 0 {
+		pc.AddError(NewLocatedError("E0107", func_tok.line, func_tok.index_start, "functions can only be defined at the top level"))
+	}
+	if tg.HasNext() && reserved_name(tg.PeekNext(), "function", pc) {
+		tg.index = len(tg.toks)
+		return &BlockNode{span: pc.SpanOf(tg.LastToken())}
+	}
+	if !tg.HasNext() || tg.PeekNext().TokenType != Name_TType {
+		pc.AddError(NewLocatedError("E0105", func_tok.line, func_tok.index_end, "expected the name of the function"))
+		tg.index = len(tg.toks)
+		return &BlockNode{span: pc.SpanOf(tg.LastToken())}
+	}
+	name_tok := tg.ConsumeNext()
+	fd := &FunctionDefinition{name: name_tok.text, returnType: NoType, span: pc.SpanBetween(func_tok, name_tok), doc: lg.doc}
+	if _, exists := pc.functions[fd.name]; exists {
+		pc.AddError(NewLocatedError("E0204", name_tok.line, name_tok.index_start, fmt.Sprintf("function %s is already defined", fd.name)))
+	} else if pc.host.lookup(fd.name) != nil {
+		pc.AddError(NewLocatedError("E0204", name_tok.line, name_tok.index_start, fmt.Sprintf("function %s is already defined by the host", fd.name)))
+	}
+	pc.functions[fd.name] = fd
+	if !tg.HasNext() || tg.PeekNext().TokenType != OpenParen {
+		pc.AddError(NewLocatedError("E0102", name_tok.line, name_tok.index_end, "expected `(` and the parameters of the function"))
+		tg.index = len(tg.toks)
+		return fd
+	}
+	tg.ConsumeNext()
+	for tg.HasNext() && tg.PeekNext().TokenType != CloseParen {
+		param_tok := tg.ConsumeNext()
+		if reserved_name(param_tok, "parameter", pc) {
+			tg.index = len(tg.toks)
+			return fd
+		}
+		if param_tok.TokenType != Name_TType || !tg.HasNext() || !is_type_token(tg.PeekNext()) {
+			pc.AddError(NewLocatedError("E0105", param_tok.line, param_tok.index_start, "expected a parameter name followed by its type"))
+			tg.index = len(tg.toks)
+			return fd
+		}
+		fd.parameterNames = append(fd.parameterNames, param_tok.text)
+		fd.parameterTypes = append(fd.parameterTypes, TreeifyType(tg.ConsumeNext(), pc))
+		if tg.HasNext() && tg.PeekNext().TokenType == Comma {
+			tg.ConsumeNext()
+		}
+	}
+	if !tg.HasNext() {
+		pc.AddError(NewLocatedError("E0102", name_tok.line, tg.LastToken().index_end, "expected `)` at the end of the parameters"))
+		return fd
+	}
+	tg.ConsumeNext() // )
+	if tg.HasNext() && is_type_token(tg.PeekNext()) {
+		fd.returnType = TreeifyType(tg.ConsumeNext(), pc)
+	}
+	fd.span = pc.SpanBetween(func_tok, tg.LastToken())
+	//functions see their parameters and the globals declared before them, the runtime copies the globals in when it is called
+	outer_vars := pc.var_types
+	pc.var_types = map[string]ValueType{}
+	for name, t := range outer_vars {
+		pc.var_types[name] = t
+	}
+	for i, name := range fd.parameterNames {
+		pc.var_types[name] = fd.parameterTypes[i]
+	}
+	pc.function = fd
+	fd.lines = TreeifyBlock(lg, pc).lines
+	pc.var_types = outer_vars
+	pc.function = nil
+	return fd
+}
+
+// option {...} {...} ...
+func TreeifyOptionStatement(lg *LineGiver, pc *ParseChecker) ASTNode {
+	option_tok := lg.current.ConsumeNext()
+	on := &OptionNode{alternatives: []*BlockNode{TreeifyBlock(lg, pc)}, span: pc.SpanOf(option_tok)}
+	for lg.current.HasNext() && lg.current.PeekNext().TokenType == OpenCurly {
+		on.alternatives = append(on.alternatives, TreeifyBlock(lg, pc))
+	}
+	return on
+}
+
+type TokenGiver struct {
+	toks  []Token
+	index int
+}
+
+func (tg *TokenGiver) HasNext() bool {
+	return tg.index < len(tg.toks)
+}
+
+func (tg *TokenGiver) PeekNext() Token {
+	return tg.toks[tg.index]
+}
+func (tg *TokenGiver) ConsumeNext() Token {
+	tg.index++
+	return tg.toks[tg.index-1]
+
+}
+
+// the last token handed out, for pointing at where something is missing
+func (tg *TokenGiver) LastToken() Token {
+	if tg.index > 0 {
+		return tg.toks[tg.index-1]
+	}
+	return tg.toks[0]
+}
+
+// true if there is nothing more to this statement on this line
+func (tg *TokenGiver) AtStatementEnd() bool {
+	return !tg.HasNext() || tg.PeekNext().TokenType == CloseCurly
+}
+
+// where the parser gets its tokens from, a *Lexer reading them as they are needed or lines that were already tokenized
+type LineSource interface {
+	Peek() (Token, bool)
+	Next() (Token, bool)
+}
+
+type TokenLines struct {
+	lines  [][]Token
+	index  int
+	buffer []Token //what is left of the line being handed out
+}
+
+func (tl *TokenLines) Peek() (Token, bool) {
+	for len(tl.buffer) == 0 {
+		if tl.index >= len(tl.lines) {
+			return Token{}, false
+		}
+		tl.buffer = tl.lines[tl.index]
+		tl.index++
+	}
+	return tl.buffer[0], true
+}
+
+func (tl *TokenLines) Next() (Token, bool) {
+	tok, ok := tl.Peek()
+	if ok {
+		tl.buffer = tl.buffer[1:]
+	}
+	return tok, ok
+}
+
+// hands out the lines of a file one at a time, skipping lines with nothing but comments
+type LineGiver struct {
+	source    LineSource
+	current   *TokenGiver
+	doc       string //the doc comment of the current line, "" if it doesn't have one
+	last_line int    //the line the last tokens handed out were on, lines after it with no tokens are blank
+}
+
+// the tokens up to the end of the line the next token is on, a raw string going over many lines
+// makes them all one line
+func (lg *LineGiver) take_line() ([]Token, bool) {
+	first, ok := lg.source.Next()
+	if !ok {
+		return nil, false
+	}
+	line := []Token{first}
+	end := first.end_line()
+	for {
+		next, ok := lg.source.Peek()
+		if !ok || next.line > end {
+			break
+		}
+		lg.source.Next()
+		line = append(line, next)
+		if next.end_line() > end {
+			end = next.end_line()
+		}
+	}
+	return line, true
+}
+
+// the next line with code on it, any /// lines right above it end up in lg.doc
+func (lg *LineGiver) NextLine() *TokenGiver {
+	doc_lines := []string{}
+	for {
+		line, ok := lg.take_line()
+		if !ok {
+			return nil
+		}
+		if line[0].line > lg.last_line+1 {
+			//a blank line means the doc comment wasn't for what comes after it
+			doc_lines = []string{}
+		}
+		lg.last_line = line[len(line)-1].end_line()
+		toks := []Token{}
+		is_doc := false
+		for _, t := range line {
+			switch t.TokenType {
+			case Comment_TType:
+			case DocComment_TType:
+				doc_lines = append(doc_lines, t.text)
+				is_doc = true
+			default:
+				toks = append(toks, t)
+			}
+		}
+		if len(toks) > 0 {
+			lg.doc = ""
+			if !is_doc {
+				lg.doc = strings.Join(doc_lines, "\n")
+			}
+			lg.current = &TokenGiver{toks: toks, index: 0}
+			return lg.current
+		}
+	}
+}
+
+func (lg *LineGiver) LastToken() Token {
+	if lg.current == nil {
+		return Token{line: 1}
+	}
+	return lg.current.LastToken()
+}
@@ -0,0 +1,121 @@
+package lang
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// the codes of everything compiling src reports, warnings too
+func compile_codes(src string) []string {
+	_, diags := CompileReader(strings.NewReader(src), CompileOptions{})
+	codes := []string{}
+	for _, d := range diags {
+		codes = append(codes, d.Code)
+	}
+	return codes
+}
+
+func TestFunctionsSeeGlobals(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"var g int = 2\nfunc f() int {\n\treturn g\n}\nprint f()", []string{}},
+		{"var g int = 2\nfunc f(n int) int {\n\treturn g * n\n}\nprint f(3)", []string{}},
+		//the runtime only has the globals declared before the call, the parser goes by before the function
+		{"func f() int {\n\treturn g\n}\nvar g int = 2\nprint f()", []string{"E0201"}},
+		{"var g int = 2\nfunc f() int {\n\treturn h\n}\nprint f()", []string{"E0201"}},
+		{"var g int = 2\nfunc f() int {\n\treturn 1\n}\nprint f()", []string{"W0101"}},
+	}
+	for _, test := range tests {
+		if got := compile_codes(test.src); strings.Join(got, " ") != strings.Join(test.want, " ") {
+			t.Errorf("%q: got %v, want %v", test.src, got, test.want)
+		}
+	}
+	if got := output_of(t, "var g int = 2\nfunc f(n int) int {\n\treturn g * n\n}\ng = 5\nprint f(3)"); got != "15\n" {
+		t.Errorf("printed %q, want the global as it is when f is called", got)
+	}
+}
+
+func TestAssignmentTypes(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{`var x int = 3`, []string{}},
+		{`var x int = "str"`, []string{"E0205"}},
+		{`var x float = 1.5`, []string{}},
+		{`var x float = 1`, []string{"E0205"}},
+		{`var s string = "a" + "b"`, []string{}},
+		{`var b bool = 1 < 2`, []string{}},
+		{`var b bool = 1 + 2`, []string{"E0205"}},
+		{"var x int = 3\nx = 1.5", []string{"E0205"}},
+		{"var x int = 3\nx = x + 1", []string{}},
+		{"var x int\nvar y string = \"a\"\nx = y", []string{"E0205"}},
+		{"func f() string {\n\treturn \"a\"\n}\nvar x int = f()", []string{"E0205"}},
+		{"func f() int {\n\treturn 1\n}\nvar x int = f()", []string{}},
+		//a call to a function defined further down is checked once it is known
+		{"var x int = f()\nfunc f() string {\n\treturn \"a\"\n}", []string{"E0205"}},
+	}
+	for _, test := range tests {
+		if got := error_codes(test.src, nil); strings.Join(got, " ") != strings.Join(test.want, " ") {
+			t.Errorf("%q: got %v, want %v", test.src, got, test.want)
+		}
+	}
+}
+
+// a bad statement is reported and skipped, the ones after it are still checked
+func TestVarNeedsAName(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"var 5 int = 3", []string{"E0105"}},
+		{"var \"x\" int", []string{"E0105"}},
+		{"var ( int", []string{"E0105"}},
+		{"var var int", []string{"E0106"}},
+		{"var 5 int = 3\nprint y", []string{"E0105", "E0201"}},
+		{"var 5 int = 3\nvar x int = 3\nprint x", []string{"E0105"}},
+	}
+	for _, test := range tests {
+		if got := error_codes(test.src, nil); strings.Join(got, " ") != strings.Join(test.want, " ") {
+			t.Errorf("%q: got %v, want %v", test.src, got, test.want)
+		}
+	}
+}
+
+// every node of every sample program knows where it came from
+func TestEveryNodeHasASpan(t *testing.T) {
+	files, _ := filepath.Glob("../*.lang")
+	benches, _ := filepath.Glob("../bench/*.lang")
+	for _, file := range append(files, benches...) {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prog, diags := CompileReader(strings.NewReader(string(src)), CompileOptions{File: file})
+		if prog == nil {
+			t.Fatalf("%s: %v", file, diags)
+		}
+		var check func(n ASTNode)
+		check = func(n ASTNode) {
+			span := n.Span()
+			if span.file != file || span.line < 1 || span.line > len(prog.lines) || span.end <= span.start && span.column_end <= span.column_start {
+				t.Errorf("%s: %T has the span %+v", file, n, span)
+			}
+			for _, child := range ast_children(n) {
+				check(child)
+			}
+			if fd, is_function := n.(*FunctionDefinition); is_function {
+				for _, line := range fd.lines {
+					check(line)
+				}
+			}
+		}
+		for _, line := range prog.ast {
+			check(line)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package lang
+
+import "fmt"
+
+type Value interface {
+	Name() string
+	String() string
+	Type() ValueType
+}
+
+var _ Value = &BoolType{name: "a", value: false}
+var _ Value = &IntType{name: "a", value: 20}
+var _ Value = &FloatType{name: "a", value: 0.5}
+var _ Value = &TupleType{}
+var _ Value = &StringType{}
+var _ Value = &UniverseType{}
+var _ Value = &SolutionSetType{}
+
+type BoolType struct {
+	name  string
+	value bool
+}
+
+func (b *BoolType) Name() string {
+	return b.name
+}
+func (b *BoolType) String() string {
+	return fmt.Sprint(b.value)
+}
+
+func (b *BoolType) Type() ValueType {
+	return Bool
+}
+
+// an int variable
+type IntType struct {
+	name  string
+	value int
+}
+
+func (*IntType) Type() ValueType {
+	return Int
+}
+func (i *IntType) Name() string {
+	return i.name
+}
+func (i *IntType) String() string {
+	return fmt.Sprint(i.value)
+}
+
+// a float variable
+type FloatType struct {
+	name  string
+	value float64
+}
+
+func (*FloatType) Type() ValueType {
+	return Float
+}
+func (f *FloatType) Name() string {
+	return f.name
+}
+func (f *FloatType) String() string {
+	return fmt.Sprint(f.value)
+}
+
+type TupleType struct {
+	name   string
+	values []Value
+}
+
+func (tt *TupleType) Name() string {
+
+	return tt.name
+}
+func (tt *TupleType) String() string {
+	return "<" + format_values(nil, tt.values) + ">"
+}
+
+// Type implements Value
+func (*TupleType) Type() ValueType {
+	return Tuple
+}
+
+type StringType struct {
+	name  string
+	value string
+}
+
+func (s *StringType) Name() string {
+	return s.name
+}
+func (s *StringType) String() string {
+	return s.value
+}
+func (*StringType) Type() ValueType {
+	return String
+}
+
+// a universe of discourse, its statements are kept unevaluated so a solver can evaluate them under different assignments
+type UniverseType struct {
+	name       string
+	statements []ASTNode
+}
+
+func (u *UniverseType) Name() string {
+	return u.name
+}
+func (u *UniverseType) String() string {
+	return fmt.Sprintf("universe of %d statements", len(u.statements))
+}
+func (*UniverseType) Type() ValueType {
+	return Universe
+}
+
+// what a solve block binds its results name to, solutions are searched for as they are needed
+type SolutionSetType struct {
+	name      string
+	solutions []*Solution
+	search    *solve_search //nil once every solution has been found
+	node      *SolveNode
+	limit     int //-1 if there is no limit
+}
+
+// the ith solution, searching for more if it has not been found yet
+func (ss *SolutionSetType) At(r *Runtime, i int) (*Solution, bool) {
+	for i >= len(ss.solutions) && ss.search != nil {
+		if ss.limit >= 0 && len(ss.solutions) >= ss.limit {
+			ss.search = nil
+			break
+		}
+		sol, found := ss.search.Next(r)
+		if !found {
+			ss.search = nil
+			break
+		}
+		ss.solutions = append(ss.solutions, sol)
+		if !r.check_length(ss, ss.node.span) {
+			ss.search = nil
+			break
+		}
+	}
+	if i < len(ss.solutions) {
+		return ss.solutions[i], true
+	}
+	return nil, false
+}
+
+// finds every solution, for printing
+func (ss *SolutionSetType) Materialize(r *Runtime) {
+	for ss.search != nil {
+		ss.At(r, len(ss.solutions))
+	}
+}
+
+// how many solutions there are, without holding on to them
+func (ss *SolutionSetType) Count(r *Runtime) int {
+	if ss.search == nil {
+		return len(ss.solutions)
+	}
+	//counted with the variables the set was solved with, like the rest of its solutions will be found with
+	r.scope_stack = append(r.scope_stack, ss.search.scope)
+	se, ok := ss.node.new_search(r, map[*RequireNode]bool{})
+	r.PopScope()
+	if !ok {
+		return 0
+	}
+	count := 0
+	for ss.limit < 0 || count < ss.limit {
+		if _, found := se.Next(r); !found {
+			break
+		}
+		count++
+	}
+	return count
+}
+
+func (ss *SolutionSetType) Name() string {
+	return ss.name
+}
+func (ss *SolutionSetType) String() string {
+	if len(ss.solutions) == 0 {
+		return "no solutions"
+	}
+	s := ""
+	for i, sol := range ss.solutions {
+		s += sol.String()
+		if i < len(ss.solutions)-1 {
+			s += "\n"
+		}
+	}
+	if ss.search != nil {
+		s += "\n..."
+	}
+	return s
+}
+func (*SolutionSetType) Type() ValueType {
+	return SolutionSet
+}
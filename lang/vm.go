@@ -0,0 +1,291 @@
+package lang
+
+import (
+	"context"
+	"fmt"
+)
+
+// runs bytecode on a stack of values instead of walking the tree, with the same scopes, steps, errors and output
+// so a program can't tell which one is running it
+type vm struct {
+	r       *Runtime
+	stack   []Value
+	locals  []Value //the slots of every function running, see full
+	defined []bool  //which slots have been declared or set
+	base    int     //where the slots of the function running start
+	light   bool    //the function running keeps its variables in slots instead of a scope
+}
+
+// a for loop that is running
+type loop struct {
+	set      *SolutionSetType
+	i        int
+	variable string
+}
+
+// RunContext for a program compiled with compile_program
+func (r *Runtime) run_compiled(ctx context.Context, code *bytecode) error {
+	r.ctx = ctx
+	defer func() { r.ctx = no_context }()
+	m := &vm{r: r, stack: make([]Value, 0, 64)}
+	for r.current_line < len(r.ASTLines) {
+		line := r.ASTLines[r.current_line]
+		if !r.step(line.Span()) {
+			return r.last_error
+		}
+		r.tracef("line %d: %+v\n", r.current_line, line)
+
+		m.run(code.lines[r.current_line])
+		r.scope_stack = r.scope_stack[:1]
+		m.stack = m.stack[:0]
+		if r.last_error != nil {
+			return r.last_error
+		}
+
+		r.current_line++
+	}
+	return nil
+}
+
+func (m *vm) push(v Value) {
+	m.stack = append(m.stack, v)
+	m.r.last_expression_result = v
+}
+
+func (m *vm) pop() Value {
+	v := m.stack[len(m.stack)-1]
+	m.stack = m.stack[:len(m.stack)-1]
+	return v
+}
+
+// the top n values, oldest first
+func (m *vm) pop_n(n int) []Value {
+	values := make([]Value, n)
+	copy(values, m.stack[len(m.stack)-n:])
+	m.stack = m.stack[:len(m.stack)-n]
+	return values
+}
+
+// a call keeps its variables in slots and looks in the globals for anything else, making a scope with
+// the globals copied in is most of what a call costs the tree walker
+// nothing can change a global while a function runs, so the scope can be made later and be the same,
+// that has to happen before anything that might hand the scope to the tree walker, after it the function
+// uses the scope by name like the top level does
+func (m *vm) full(c *chunk) {
+	if !m.light {
+		return
+	}
+	scope := EmptyScope()
+	for i, name := range c.locals {
+		if m.defined[m.base+i] {
+			scope.variables[name] = m.locals[m.base+i]
+		}
+	}
+	scope.Merge(m.r.global_scope)
+	m.r.scope_stack = append(m.r.scope_stack, scope)
+	m.light = false
+}
+
+// true if the tree walker could go looking at variables while using v, like searching a solution set
+func needs_scope(v Value) bool {
+	switch v.(type) {
+	case *BoolType, *IntType, *FloatType, *StringType, *Solution:
+		return false
+	}
+	return true
+}
+
+// runs c until it ends or the runtime halts, whoever called it cleans up the scopes and stack it leaves
+func (m *vm) run(c *chunk) {
+	r := m.r
+	var loops []loop
+	for pc := 0; pc < len(c.code); pc++ {
+		in := c.code[pc]
+		switch in.op {
+		case op_const:
+			m.push(c.constants[in.arg])
+		case op_get:
+			gn := c.nodes[in.arg].(*GetNode)
+			scope := r.StackTop()
+			if m.light {
+				scope = r.global_scope
+			}
+			v, exists := scope.variables[gn.name]
+			if !exists {
+				gn.Execute(r)
+				return
+			}
+			m.push(v)
+		case op_set:
+			r.StackTop().set(c.names[in.arg], m.pop())
+		case op_declare:
+			r.StackTop().declare(c.names[in.arg])
+		case op_get_local:
+			get := c.gets[in.arg]
+			if !m.light {
+				v, exists := r.StackTop().variables[get.node.name]
+				if !exists {
+					get.node.Execute(r)
+					return
+				}
+				m.push(v)
+			} else if m.defined[m.base+get.slot] {
+				m.push(m.locals[m.base+get.slot])
+			} else if v, exists := r.global_scope.variables[get.node.name]; exists {
+				m.push(v)
+			} else {
+				r.throwError(UndefinedVariableError, get.node.span, fmt.Sprintf("undefined variable %s", get.node.name))
+				return
+			}
+		case op_set_local:
+			if m.light {
+				m.locals[m.base+in.arg] = m.pop()
+				m.defined[m.base+in.arg] = true
+			} else {
+				r.StackTop().set(c.locals[in.arg], m.pop())
+			}
+		case op_declare_local:
+			if m.light {
+				m.locals[m.base+in.arg] = nil
+				m.defined[m.base+in.arg] = true
+			} else {
+				r.StackTop().declare(c.locals[in.arg])
+			}
+		case op_declare_range:
+			m.full(c)
+			high := m.pop()
+			c.nodes[in.arg].(*DeclareNode).set_range(r, m.pop(), high)
+		case op_binary:
+			rval := m.pop()
+			c.nodes[in.arg].(*BinaryOpNode).apply(r, m.pop(), rval)
+			m.stack = append(m.stack, r.last_expression_result)
+		case op_negate:
+			c.nodes[in.arg].(*NegateNode).negate(r, m.pop())
+			m.stack = append(m.stack, r.last_expression_result)
+		case op_check_index:
+			if _, is_int := m.stack[len(m.stack)-1].(*IntType); !is_int {
+				r.throwError(TypeMismatchError, c.nodes[in.arg].Span(), "index must be an int")
+			}
+		case op_index:
+			target := m.pop()
+			if _, is_tuple := target.(*TupleType); !is_tuple {
+				m.full(c)
+			}
+			c.nodes[in.arg].(*IndexNode).index_into(r, target, m.pop().(*IntType))
+			m.stack = append(m.stack, r.last_expression_result)
+		case op_property:
+			if needs_scope(m.stack[len(m.stack)-1]) {
+				m.full(c)
+			}
+			c.nodes[in.arg].(*PropertyNode).get(r, m.pop())
+			m.stack = append(m.stack, r.last_expression_result)
+		case op_tuple:
+			tl := c.nodes[in.arg].(*TupleLiteral)
+			tl.make(r, m.pop_n(len(tl.values)))
+			m.stack = append(m.stack, r.last_expression_result)
+		case op_print:
+			if needs_scope(m.stack[len(m.stack)-1]) {
+				m.full(c)
+			}
+			c.nodes[in.arg].(*PrintStatement).print(r, m.pop())
+		case op_call:
+			site := c.calls[in.arg]
+			if !r.can_call(site.node.name, site.node.span) {
+				return
+			}
+			m.push(m.call(site.function, site.node.span))
+		case op_call_host:
+			site := c.calls[in.arg]
+			site.node.call_host_with(r, site.host, m.pop_n(len(site.node.args)))
+			m.stack = append(m.stack, r.last_expression_result)
+		case op_return:
+			r.return_value = m.pop()
+			r.returning = true
+			return
+		case op_return_nothing:
+			r.return_value = nil
+			r.returning = true
+			return
+		case op_pop:
+			m.pop()
+		case op_step:
+			if !r.step(c.nodes[in.arg].Span()) {
+				return
+			}
+		case op_for:
+			m.full(c)
+			fn := c.nodes[in.arg].(*ForInNode)
+			set, is_set := m.pop().(*SolutionSetType)
+			r.last_expression_result = nil
+			if !is_set {
+				r.throwError(TypeMismatchError, fn.span, "can only loop over a solution set")
+				return
+			}
+			loops = append(loops, loop{set: set, variable: fn.variable})
+			r.NewLoopScope(fn.variable)
+		case op_next:
+			l := &loops[len(loops)-1]
+			sol, found := l.set.At(r, l.i)
+			if r.last_error != nil {
+				return
+			}
+			if !found {
+				loops = loops[:len(loops)-1]
+				r.PopScope()
+				pc = in.arg - 1
+				continue
+			}
+			l.i++
+			r.StackTop().next_iteration(l.variable, sol)
+		case op_end_loop:
+			pc = in.arg - 1
+		case op_walk:
+			m.full(c)
+			c.nodes[in.arg].Execute(r)
+		case op_walk_value:
+			m.full(c)
+			c.nodes[in.arg].Execute(r)
+			m.stack = append(m.stack, r.last_expression_result)
+		}
+		if r.halted() {
+			return
+		}
+	}
+}
+
+// FunctionDefinition.Call for a compiled function, the arguments are on the stack
+func (m *vm) call(fn *compiled_function, from Span) Value {
+	r := m.r
+	r.call_stack = append(r.call_stack, Frame{function: fn.definition.name, called_from: from})
+	scopes, base, light := len(r.scope_stack), m.base, m.light
+	m.base, m.light = len(m.locals), true
+	for range fn.body.locals {
+		m.locals = append(m.locals, nil)
+		m.defined = append(m.defined, false)
+	}
+	args := m.stack[len(m.stack)-len(fn.params):]
+	for i, slot := range fn.params {
+		m.locals[m.base+slot] = args[i]
+		m.defined[m.base+slot] = true
+	}
+	m.stack = m.stack[:len(m.stack)-len(fn.params)]
+	height := len(m.stack)
+	r.last_expression_result = nil
+	m.run(fn.body)
+	var result Value
+	if r.returning {
+		result = r.return_value
+		r.returning = false
+		r.return_value = nil
+	}
+	r.scope_stack = r.scope_stack[:scopes]
+	for i := m.base; i < len(m.locals); i++ {
+		m.locals[i] = nil
+	}
+	m.locals, m.defined = m.locals[:m.base], m.defined[:m.base]
+	m.base, m.light = base, light
+	m.stack = m.stack[:height]
+	r.call_stack = r.call_stack[:len(r.call_stack)-1]
+	r.last_expression_result = result
+	return result
+}
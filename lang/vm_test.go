@@ -0,0 +1,84 @@
+package lang
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// the nodes the vm hands to the tree walker in prog, by type
+func walked_nodes(prog *Program) []string {
+	walked := []string{}
+	check := func(c *chunk) {
+		for _, in := range c.code {
+			if in.op == op_walk || in.op == op_walk_value {
+				walked = append(walked, strings.TrimPrefix(fmt.Sprintf("%T", c.nodes[in.arg]), "*lang."))
+			}
+		}
+	}
+	for _, line := range prog.code.lines {
+		check(line)
+	}
+	for _, fn := range prog.code.functions {
+		check(fn.body)
+	}
+	return walked
+}
+
+// only what has to do with solving is left to the tree walker, loops over solutions run as bytecode
+func TestCompiled(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"var a int = 1\nvar b float = 2.5\nprint (a + 2, -b, a < 3, \"s\" + \"t\")", []string{}},
+		{"var t tuple = (1, (2, 3))\nprint t[1][0]", []string{}},
+		{"func f(n int) int {\n\tvar m int = n * 2\n\treturn m\n}\nf(1)\nprint f(2)", []string{}},
+		{"var x int in 0..3\nvar u universe = (x)\nsolve u, s {\n\trequire x > 1\n}\nvar total int = 0\nfor a in s {\n\ttotal = total + a.x\n}\nprint s.count",
+			[]string{"UniverseLiteral", "SolveNode"}},
+		{"var a int = 0\noption {\n\ta = 1\n}", []string{"OptionNode"}},
+	}
+	for _, test := range tests {
+		prog, diags := Compile(test.src)
+		if prog == nil {
+			t.Fatalf("%q: %v", test.src, diags)
+		}
+		if got := walked_nodes(prog); strings.Join(got, " ") != strings.Join(test.want, " ") {
+			t.Errorf("%q: walked %v, want %v", test.src, got, test.want)
+		}
+	}
+}
+
+// the vm has to print the same and stop with the same errors as the tree walker, output_of checks the printing
+func TestSameAsTreeWalker(t *testing.T) {
+	programs := []string{
+		"print 7 / 2\nprint 7 % 3\nprint 7.0 / 2.0\nprint 1 - 2 * 3",
+		"print 1 < 2\nprint 2 <= 1\nprint 1 == 1\nprint 1 != 1\nprint true && false\nprint true || false\nprint !true",
+		"print \"a\" + \"b\"\nprint \"a\" == \"a\"",
+		"var a int = 1\na = a + 1\nprint a\nvar a int = 5\nprint a",
+		"func f(n int) int {\n\treturn n * 2\n}\nvar g int = 3\nprint f(g) + f(f(1))",
+		"func noisy(n int) {\n\tprint n\n\treturn\n\tprint 0\n}\nnoisy(4)",
+		"var t tuple = (1, \"two\", (3, 4))\nprint t\nprint t[2][1]",
+		"var x int in 0..4\nvar u universe = (x)\nsolve u, s {\n\trequire x % 2 == 0\n}\nfor a in s {\n\tfor b in s {\n\t\tprint a.x * b.x\n\t}\n}",
+	}
+	for _, src := range programs {
+		output_of(t, src)
+	}
+	failing := []string{
+		"print 1 / 0",
+		"print 1.0 / 0",
+		"var t tuple = (1, 2)\nprint t[2]",
+		"var t tuple = (1, 2)\nprint t[-1]",
+		"var n int\nprint n * 2",
+		"print \"a\" * 2",
+		"func f(n int) int {\n\treturn f(n)\n}\nprint f(1)",
+		"func f(n int) int {\n\treturn 1 / n\n}\nprint f(1)\nprint f(0)",
+	}
+	for _, src := range failing {
+		walked_out, walked := run_source(t, src, false)
+		compiled_out, compiled := run_source(t, src, true)
+		if walked == nil || compiled == nil || walked.Error() != compiled.Error() || walked_out != compiled_out {
+			t.Errorf("%q: the tree walker printed %q and stopped with %v, the vm printed %q and stopped with %v", src, walked_out, walked, compiled_out, compiled)
+		}
+	}
+}
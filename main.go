@@ -1,8 +1,15 @@
 package main
 
 import (
+	"Lang/lang"
+	"context"
 	_ "embed"
+	"flag"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 func main() {
@@ -35,9 +42,130 @@ func main() {
 	//line_src := "var a vec<int> = [1 2 3 4]"
 	//line_src := "var a var = 0"
 	line_src := "var abcde = 0"
-	fmt.Println(line_src)
-	toks := Tokenize(line_src)
-	MakeTree(toks, line_src)
-	fmt.Println(toks)
+	file := "<main>"
+	format := flag.String("diagnostics", "text", "how to report errors: text, json or sarif (json and sarif go to stderr)")
+	lint_config_path := flag.String("lint-config", "", "lint rule settings, defaults to .langlint next to the source if there is one")
+	var limits lang.Limits
+	flag.IntVar(&limits.Steps, "max-steps", 0, "stop after this many statements and solve branches, 0 for no limit")
+	flag.IntVar(&limits.CallDepth, "max-depth", 0, "how many calls can be running at once, 0 for the default of 10000")
+	flag.IntVar(&limits.MaxLength, "max-length", 0, "longest string, tuple or solution set, 0 for no limit")
+	timeout := flag.Duration("timeout", 0, "stop the program after this long, 0 for never")
+	trace := flag.Bool("trace", false, "say what the parser and runtime are doing on stderr")
+	use_vm := flag.Bool("vm", false, "run the program as bytecode on a stack machine instead of walking the tree")
+	flag.Parse()
+	if *format != "text" && *format != "json" && *format != "sarif" {
+		fmt.Fprintf(os.Stderr, "unknown diagnostics format %s, expected text, json or sarif\n", *format)
+		os.Exit(2)
+	}
+	args := flag.Args()
+	//lang explain E0201 says more about an error code, lang explain lists them
+	if len(args) > 0 && args[0] == "explain" {
+		explain(args[1:])
+		return
+	}
+	//lang bench times the tree walker against the vm
+	if len(args) > 0 && args[0] == "bench" {
+		bench(args[1:])
+		return
+	}
+	//lang doc file.lang prints the doc comments instead of running it
+	doc_mode := len(args) > 1 && args[0] == "doc"
+	if doc_mode {
+		args = args[1:]
+	}
+	var source io.Reader = strings.NewReader(line_src)
+	if len(args) > 0 && args[0] == "-" {
+		//piped in
+		file = "<stdin>"
+		source = os.Stdin
+	} else if len(args) > 0 {
+		file = args[0]
+		f, err := os.Open(file)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		source = f
+	}
+	var trace_to io.Writer
+	if *trace {
+		trace_to = os.Stderr
+	}
+	prog, diags := lang.CompileReader(source, lang.CompileOptions{File: file, LintConfigPath: find_lint_config(*lint_config_path, file), Trace: trace_to})
+	if prog == nil {
+		report(*format, diags)
+		os.Exit(1)
+	}
+	if doc_mode {
+		for _, entry := range prog.Docs() {
+			fmt.Println(entry)
+		}
+		report(*format, diags)
+		return
+	}
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	res, err := prog.Run(ctx, lang.RunOptions{Limits: limits, Output: os.Stdout, Trace: trace_to, VM: *use_vm})
+	report(*format, append(diags, res.Diagnostics...))
+	if err != nil {
+		os.Exit(1)
+	}
+
+}
+
+// says diags the way -diagnostics asked for
+func report(format string, diags []lang.Diagnostic) {
+	if format == "text" {
+		for _, d := range diags {
+			fmt.Println(d)
+		}
+		return
+	}
+	//on stderr so it doesn't get mixed up with what the program prints
+	var err error
+	if format == "json" {
+		err = lang.WriteJSONDiagnostics(os.Stderr, diags)
+	} else {
+		err = lang.WriteSARIFDiagnostics(os.Stderr, diags)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+func explain(codes []string) {
+	if len(codes) == 0 {
+		for _, line := range lang.ExplanationIndex() {
+			fmt.Println(line)
+		}
+		return
+	}
+	for i, code := range codes {
+		ex, err := lang.Explain(code)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Print(ex)
+	}
+}
 
+// the config at path, or .langlint beside the source file if there is one, "" for the defaults
+func find_lint_config(path string, source_file string) string {
+	if path != "" {
+		return path
+	}
+	beside := filepath.Join(filepath.Dir(source_file), ".langlint")
+	if _, err := os.Stat(beside); err != nil {
+		return ""
+	}
+	return beside
 }